@@ -7,35 +7,59 @@ import (
 	cfEventSender "github.com/codefresh-io/argocd-listener/installer/pkg/cfeventsender"
 	agentInstaller "github.com/codefresh-io/argocd-listener/installer/pkg/install"
 	agentInstallPkg "github.com/codefresh-io/argocd-listener/installer/pkg/install/entity"
-	"github.com/codefresh-io/argocd-listener/installer/pkg/logger"
-	"github.com/codefresh-io/argocd-listener/installer/pkg/prompt"
+	agentUninstaller "github.com/codefresh-io/argocd-listener/installer/pkg/uninstall/handler"
 	argoSdk "github.com/codefresh-io/argocd-sdk/pkg/api"
 	argo "github.com/codefresh-io/cf-gitops-controller/pkg/argo"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/checkpoint"
 	"github.com/codefresh-io/cf-gitops-controller/pkg/clusters"
 	"github.com/codefresh-io/cf-gitops-controller/pkg/git"
 	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
 	"github.com/codefresh-io/cf-gitops-controller/pkg/kube"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/logger"
 	"github.com/codefresh-io/cf-gitops-controller/pkg/questionnaire"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/rollback"
 	"github.com/codefresh-io/go-sdk/pkg/codefresh"
 	"github.com/janeczku/go-spinner"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"io/ioutil"
 	"os"
 	"os/user"
 	"path"
+	"strconv"
+	"strings"
 	"time"
 )
 
 var agentVersion = ""
 var installCmdOptions = install.CmdOptions{}
 
-func retrieveArgoHost(kubeClient kube.Kube) (string, error) {
+// generatedPasswordSecret keeps generated admin password when --password-secret is not set
+const generatedPasswordSecret = "cf-gitops-admin-password"
+
+// installRun keeps what outlives runInstall and is released by the install command
+type installRun struct {
+	journal *rollback.Journal
+	// stopPortForward closes argocd-server port-forward, it's kept open until the rollback is done
+	stopPortForward func()
+}
+
+func retrieveArgoHost(kubeClient kube.Kube, expose string) (string, error) {
+	if expose == install.ExposeNone {
+		// nothing to resolve the host from, retrying doesn't help
+		return "", errors.New(fmt.Sprint("Argocd server is not exposed, use --argo-host"))
+	}
 	var argoHost string
 	var err error
 	start := time.Now()
-	s := spinner.StartNew("Getting argocd ip address...")
+	if !installCmdOptions.Installer.DryRun {
+		// spinner writes to stdout
+		s := spinner.StartNew("Getting argocd ip address...")
+		defer s.Stop()
+	}
 	for {
-		argoHost, err = kubeClient.GetArgoServerHost()
+		argoHost, err = kubeClient.GetArgoServerHost(expose)
 		if err == nil {
 			break
 		}
@@ -44,7 +68,6 @@ func retrieveArgoHost(kubeClient kube.Kube) (string, error) {
 			return "", errors.New("Failed to retrieve argocd host")
 		}
 	}
-	s.Stop()
 	return argoHost, err
 }
 
@@ -53,63 +76,244 @@ var installCmd = &cobra.Command{
 	Short: "Install gitops codefresh",
 	Long:  `Install gitops codefresh`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		logger.Success("This installer will guide you through the Codefresh Gitops controller installation")
+		run := &installRun{journal: rollback.New(), stopPortForward: func() {}}
+		// stopPortForward is replaced once the port-forward is opened
+		defer func() {
+			run.stopPortForward()
+		}()
+		err := runInstall(cmd, run)
+		if err != nil && !installCmdOptions.Installer.DryRun && !installCmdOptions.Installer.KeepOnFailure {
+			logger.Warning(fmt.Sprint("Rolling back the installation, use --keep-on-failure to keep it for resuming..."))
+			rollbackErr := run.journal.Rollback()
+			if rollbackErr != nil {
+				logger.Error(rollbackErr.Error())
+			}
+		}
+		return err
+	},
+}
 
-		_ = questionnaire.AskAboutCodefreshCredentials(&installCmdOptions)
+func runInstall(cmd *cobra.Command, run *installRun) error {
+	journal := run.journal
+	err := loadAnswersFile(cmd, &installCmdOptions)
+	if err != nil {
+		return failInstallation(fmt.Sprintf("Can't load answers file: \"%s\"", err.Error()))
+	}
 
-		codefreshApi := codefresh.New(&codefresh.ClientOptions{
-			Host: installCmdOptions.Codefresh.Host,
-			Auth: codefresh.AuthOptions{
-				Token: installCmdOptions.Codefresh.Auth.Token,
-			},
-		})
+	if installCmdOptions.Installer.DryRun {
+		// progress goes to stderr, so stdout has the plan only and json plan can be parsed
+		logger.SetOutput(cmd.ErrOrStderr())
+		defer logger.SetOutput(nil)
+	}
 
-		store.SetCodefresh(installCmdOptions.Codefresh.Host, installCmdOptions.Codefresh.Auth.Token, "")
+	logger.Success("This installer will guide you through the Codefresh Gitops controller installation")
 
-		// kube context
-		_ = questionnaire.AskAboutKubeContext(&installCmdOptions)
-		kubeOptions := installCmdOptions.Kube
-		kubeClient, err := kube.New(&kube.Options{
-			ContextName:      kubeOptions.Context,
-			Namespace:        kubeOptions.Namespace,
-			PathToKubeConfig: kubeOptions.ConfigPath,
-		})
+	if installCmdOptions.Questionnaire.Yes || !questionnaire.IsInteractive() {
+		missing := questionnaire.MissingAnswers(&installCmdOptions)
+		if len(missing) > 0 {
+			return failInstallation(fmt.Sprintf("Can't run installation without prompts, missing answers: %s", strings.Join(missing, ", ")))
+		}
+	}
+	prompter := newPrompter(&installCmdOptions)
+
+	// rbac policy is validated before any change in the cluster
+	err = argo.LoadRbacPolicy(&installCmdOptions.Rbac)
+	if err != nil {
+		return failInstallation(fmt.Sprintf("Can't load rbac policy: \"%s\"", err.Error()))
+	}
+	err = argo.LoadSsoConfig(&installCmdOptions.Sso)
+	if err != nil {
+		return failInstallation(fmt.Sprintf("Can't load sso config: \"%s\"", err.Error()))
+	}
+	if installCmdOptions.Git.SshKeyFile != "" {
+		sshKey, err := ioutil.ReadFile(installCmdOptions.Git.SshKeyFile)
+		if err != nil {
+			return failInstallation(fmt.Sprintf("Can't read ssh private key: \"%s\"", err.Error()))
+		}
+		installCmdOptions.Git.Auth.SshPrivateKey = string(sshKey)
+	}
+	if installCmdOptions.Git.Auth.GithubApp.PrivateKeyFile != "" {
+		appKey, err := ioutil.ReadFile(installCmdOptions.Git.Auth.GithubApp.PrivateKeyFile)
 		if err != nil {
-			return failInstallation(fmt.Sprintf("Can't create kube client: \"%s\"", err.Error()))
+			return failInstallation(fmt.Sprintf("Can't read github app private key: \"%s\"", err.Error()))
 		}
+		installCmdOptions.Git.Auth.GithubApp.PrivateKey = string(appKey)
+	}
+	if installCmdOptions.Argo.GeneratePassword && installCmdOptions.Argo.PasswordSecret == "" {
+		// generated password is never printed, the secret is the only place to read it from
+		installCmdOptions.Argo.PasswordSecret = generatedPasswordSecret
+	}
+	githubApp := installCmdOptions.Git.Auth.GithubApp
+	if githubApp.AppID != 0 && (githubApp.InstallationID == 0 || githubApp.PrivateKey == "") {
+		return failInstallation(fmt.Sprint("Github app requires installation id and private key, use --github-app-installation-id and --github-app-private-key-file"))
+	}
 
-		// namespace
-		_ = questionnaire.AskAboutNamespace(&installCmdOptions, kubeClient)
-		err = kubeClient.CreateNamespace(installCmdOptions.Kube.Namespace)
+	err = questionnaire.AskAboutCodefreshCredentials(&installCmdOptions)
+	if err != nil {
+		return failInstallation(fmt.Sprintf("Can't get codefresh credentials: \"%s\"", err.Error()))
+	}
+
+	codefreshApi := codefresh.New(&codefresh.ClientOptions{
+		Host: installCmdOptions.Codefresh.Host,
+		Auth: codefresh.AuthOptions{
+			Token: installCmdOptions.Codefresh.Auth.Token,
+		},
+	})
+
+	store.SetCodefresh(installCmdOptions.Codefresh.Host, installCmdOptions.Codefresh.Auth.Token, "")
+
+	// kube context
+	err = questionnaire.AskAboutKubeContext(prompter, &installCmdOptions)
+	if err != nil {
+		return failInstallation(fmt.Sprintf("Can't get kube context: \"%s\"", err.Error()))
+	}
+	if installCmdOptions.Installer.DryRun {
+		journal = nil
+	}
+	kubeOptions := installCmdOptions.Kube
+	kubeClient, err := kube.New(&kube.Options{
+		ContextName:      kubeOptions.Context,
+		Namespace:        kubeOptions.Namespace,
+		PathToKubeConfig: kubeOptions.ConfigPath,
+		Journal:          journal,
+	})
+	if err != nil {
+		return failInstallation(fmt.Sprintf("Can't create kube client: \"%s\"", err.Error()))
+	}
+
+	plan := &install.Plan{}
+	if installCmdOptions.Installer.DryRun {
+		kubeClient = kube.NewRecorder(kubeClient, installCmdOptions.Kube.Namespace, plan)
+	}
+
+	// namespace
+	err = questionnaire.AskAboutNamespace(prompter, &installCmdOptions, kubeClient)
+	if err != nil {
+		return failInstallation(fmt.Sprintf("Can't get namespace: \"%s\"", err.Error()))
+	}
+	err = kubeClient.CreateNamespace(installCmdOptions.Kube.Namespace)
+	if err != nil {
+		return failInstallation(fmt.Sprintf("Can't create namespace %s: \"%s\"", installCmdOptions.Kube.Namespace, err.Error()))
+	}
+
+	checkpoints := checkpoint.Disabled()
+	if !installCmdOptions.Installer.DryRun {
+		checkpoints, err = checkpoint.Load(kubeClient.GetClientSet(), installCmdOptions.Kube.Namespace)
 		if err != nil {
-			return failInstallation(fmt.Sprintf("Can't create namespace %s: \"%s\"", installCmdOptions.Kube.Namespace, err.Error()))
+			return failInstallation(fmt.Sprintf("Can't load installation checkpoints: \"%s\"", err.Error()))
 		}
+		if installCmdOptions.Installer.Restart {
+			checkpoints.Reset()
+		} else if checkpoints.IsCompleted(checkpoint.StepNamespace) {
+			logger.Info(fmt.Sprint("Resuming previous installation, use --restart to start from scratch..."))
+		}
+		// steps completed by the previous run are not in the journal, so their checkpoints are kept
+		loaded := checkpoints.Snapshot()
+		journal.Record("restore installation checkpoints", func() error {
+			checkpoints.Restore(loaded)
+			return nil
+		})
+	}
+	checkpoints.Complete(checkpoint.StepNamespace, "")
+
+	// password is set before argocd starts, so it never needs the autogenerated one
+	if installCmdOptions.Argo.DeclarativePassword {
+		err = askAboutArgoPassword(prompter, kubeClient, checkpoints)
+		if err != nil {
+			return failInstallation(fmt.Sprintf("Can't get argo password: \"%s\"", err.Error()))
+		}
+		if !checkpoints.IsCompleted(checkpoint.StepPassword) {
+			logger.Info(fmt.Sprint("Setting admin password..."))
+			err = kubeClient.SetAdminPassword(installCmdOptions.Argo.Password)
+			if err != nil {
+				return failInstallation(fmt.Sprintf("Can't set admin password: \"%s\"", err.Error()))
+			}
+			checkpoints.Complete(checkpoint.StepPassword, "")
+		}
+	}
 
-		// manifest
-		_ = questionnaire.AskAboutManifest(&installCmdOptions)
+	// manifest
+	_ = questionnaire.AskAboutManifest(&installCmdOptions)
+	plan.ManifestPath = installCmdOptions.Kube.ManifestPath
+	plan.Namespace = installCmdOptions.Kube.Namespace
+	if !checkpoints.IsCompleted(checkpoint.StepManifests) {
 		logger.Info(fmt.Sprint("Creating argocd resources..."))
 		err = kubeClient.CreateObjects(installCmdOptions.Kube.ManifestPath)
 		if err != nil {
 			return failInstallation(fmt.Sprintf("Can't create argocd resources: \"%s\"", err.Error()))
 		}
+		checkpoints.Complete(checkpoint.StepManifests, "")
+	}
 
-		_ = questionnaire.AskAboutLoadBalancer(&installCmdOptions, kubeClient)
+	logger.Info(fmt.Sprint("Waiting for argocd workloads..."))
+	err = kubeClient.WaitForWorkloads(installCmdOptions.Kube.ManifestPath, installCmdOptions.Installer.WaitTimeout)
+	if err != nil {
+		return failInstallation(fmt.Sprintf("Argocd is not ready: \"%s\"", err.Error()))
+	}
 
-		//argo ghost
-		argoHost, err := retrieveArgoHost(kubeClient)
+	if checkpoints.IsCompleted(checkpoint.StepExpose) {
+		installCmdOptions.Controller.Expose = checkpoints.Value(checkpoint.StepExpose)
+	} else {
+		err = questionnaire.AskAboutExposure(prompter, &installCmdOptions, kubeClient)
+		if err != nil {
+			return failInstallation(fmt.Sprintf("Can't expose argocd server: \"%s\"", err.Error()))
+		}
+		checkpoints.Complete(checkpoint.StepExpose, installCmdOptions.Controller.Expose)
+	}
+
+	//argo ghost
+	var argoHost string
+	if installCmdOptions.Controller.Expose == install.ExposePortForward {
+		logger.Info(fmt.Sprint("Forwarding local port to argocd server..."))
+		var stop func()
+		argoHost, stop, err = kubeClient.PortForwardArgoServer(installCmdOptions.Controller.PortForward.LocalPort)
+		if err != nil {
+			return failInstallation(fmt.Sprintf("Can't forward port to argocd server: \"%s\"", err.Error()))
+		}
+		run.stopPortForward = stop
+		if installCmdOptions.Argo.AgentHost == "" {
+			installCmdOptions.Argo.AgentHost = kube.ArgoServerServiceHost(installCmdOptions.Kube.Namespace)
+		}
+	} else {
+		argoHost = checkpoints.Value(checkpoint.StepHost)
+	}
+	if argoHost == "" {
+		argoHost = installCmdOptions.Argo.Host
+	}
+	if argoHost == "" {
+		argoHost, err = retrieveArgoHost(kubeClient, installCmdOptions.Controller.Expose)
 		if err != nil {
 			return failInstallation(fmt.Sprintf("Can't retrieve argo host: \"%s\"", err.Error()))
 		}
-		installCmdOptions.Argo.Host = argoHost
+		checkpoints.Complete(checkpoint.StepHost, argoHost)
+	}
+	installCmdOptions.Argo.Host = argoHost
+	plan.ArgoHost = argoHost
 
-		pass := installCmdOptions.Argo.Password
-		if installCmdOptions.Argo.Password == "" {
-			// default pass
-			logger.Info(fmt.Sprint("Getting autogenerated password..."))
-			pass, err = kubeClient.GetAutogeneratedPassword()
-			if err != nil {
-				return failInstallation(fmt.Sprintf("Can't get autogenerated password: \"%s\"", err.Error()))
-			}
+	// changing pass
+	err = askAboutArgoPassword(prompter, kubeClient, checkpoints)
+	if err != nil {
+		return failInstallation(fmt.Sprintf("Can't get argo password: \"%s\"", err.Error()))
+	}
+
+	var argoApi argo.Api
+	if installCmdOptions.Installer.DryRun {
+		argoApi = argo.NewRecorder(plan)
+		if !installCmdOptions.Argo.DeclarativePassword {
+			_ = argoApi.UpdatePassword(argoSdk.UpdatePasswordOpt{
+				UserName:    installCmdOptions.Argo.Username,
+				NewPassword: installCmdOptions.Argo.Password,
+			})
+		}
+		if installCmdOptions.Argo.DeleteInitialPassword {
+			_ = kubeClient.DeleteInitialAdminSecret()
+		}
+	} else if !checkpoints.IsCompleted(checkpoint.StepPassword) {
+		// default pass
+		logger.Info(fmt.Sprint("Getting autogenerated password..."))
+		pass, err := kubeClient.GetAutogeneratedPassword(installCmdOptions.Installer.WaitTimeout)
+		if err != nil {
+			return failInstallation(fmt.Sprintf("Can't get autogenerated password: \"%s\"", err.Error()))
 		}
 
 		// getting token
@@ -121,13 +325,10 @@ var installCmd = &cobra.Command{
 			return failInstallation(fmt.Sprintf("Can't get argo token: \"%s\"", err.Error()))
 		}
 
-		argoClientOptions := argoSdk.ClientOptions{Auth: argoSdk.AuthOptions{Token: token}, Host: argoHost}
-		argoApi := argoSdk.New(&argoClientOptions)
+		argoApi = argo.New(argoHost, token, journal)
 
-		// changing pass
-		_ = questionnaire.AskAboutPass(&installCmdOptions)
 		logger.Info(fmt.Sprint("\nUpdating admin password..."))
-		err = argoApi.Auth().UpdatePassword(argoSdk.UpdatePasswordOpt{
+		err = argoApi.UpdatePassword(argoSdk.UpdatePasswordOpt{
 			CurrentPassword: pass,
 			UserName:        installCmdOptions.Argo.Username,
 			NewPassword:     installCmdOptions.Argo.Password,
@@ -135,75 +336,244 @@ var installCmd = &cobra.Command{
 		if err != nil {
 			return failInstallation(fmt.Sprintf("Can't update user pass: \"%s\"", err.Error()))
 		}
+		if installCmdOptions.Argo.DeleteInitialPassword {
+			err = kubeClient.DeleteInitialAdminSecret()
+			if err != nil {
+				logger.Warning(fmt.Sprintf("Can't delete initial admin secret: \"%s\"", err.Error()))
+			}
+		}
+		checkpoints.Complete(checkpoint.StepPassword, "")
+	}
+
+	err = storeArgoPassword(kubeClient, &installCmdOptions)
+	if err != nil {
+		return failInstallation(fmt.Sprintf("Can't store argo password: \"%s\"", err.Error()))
+	}
 
+	if !installCmdOptions.Installer.DryRun {
 		// update argo client @todo - only if user add clusters or repo
 		logger.Info(fmt.Sprint("Updating argo client..."))
-		token, err = argoSdk.GetToken(installCmdOptions.Argo.Username, installCmdOptions.Argo.Password, argoHost)
+		token, err := questionnaire.NewArgocdTokenQuestion(installCmdOptions.Argo.Username, installCmdOptions.Argo.Password, argoHost).Ask()
 		if err != nil {
 			return failInstallation(fmt.Sprintf("Can't get argo token: \"%s\"", err.Error()))
 		}
 		installCmdOptions.Argo.Token = token
-		argoClientOptions = argoSdk.ClientOptions{Auth: argoSdk.AuthOptions{Token: token}, Host: argoHost}
-		argoApi = argoSdk.New(&argoClientOptions)
-
-		_, addClusters := prompt.NewPrompt().Confirm("Would you like to integrate clusters from your account to ArgoCD?")
+		if argoApi == nil {
+			argoApi = argo.New(argoHost, token, journal)
+		} else {
+			argoApi.SetToken(token)
+		}
+	}
 
-		if addClusters {
+	if !checkpoints.IsCompleted(checkpoint.StepClusters) {
+		importClusters, err := questionnaire.AskAboutClustersImport(prompter, &installCmdOptions)
+		if err != nil {
+			return failInstallation(err.Error())
+		}
+		if importClusters {
 			//clusters
 			logger.Info(fmt.Sprint("Getting argocd clusters..."))
 			clustersList, err := clusters.GetAvailableClusters(codefreshApi.Clusters())
 			if err != nil {
 				return failInstallation(fmt.Sprintf("Can't get argocd clusters: \"%s\"", err.Error()))
 			}
-			_ = questionnaire.AskAboutClusters(&installCmdOptions, clustersList)
-			err = clusters.ImportFromCodefresh(installCmdOptions.Codefresh.Clusters, codefreshApi.Clusters(), argoApi.Clusters())
+			err = questionnaire.AskAboutClusters(prompter, &installCmdOptions, clustersList)
+			if err != nil {
+				return failInstallation(fmt.Sprintf("Can't select clusters: \"%s\"", err.Error()))
+			}
+			err = clusters.ImportFromCodefresh(installCmdOptions.Codefresh.Clusters, codefreshApi.Clusters(), argoApi, kubeClient, installCmdOptions.ClusterApplicationSet.RepoUrl != "")
 			if err != nil {
 				return failInstallation(fmt.Sprintf("Can't import clusters: \"%s\"", err.Error()))
 			}
 		}
+		checkpoints.Complete(checkpoint.StepClusters, "")
+	}
 
-		_, addManifestRepo := prompt.NewPrompt().Confirm("Would you like to integrate git context for manifest repo from your account to ArgoCD?")
-		if addManifestRepo {
-			// git repo
-			contexts, err := git.GetAvailableContexts(codefreshApi.Contexts())
-			if err != nil {
-				return failInstallation(fmt.Sprintf("Can't get git contexts: \"%s\"", err.Error()))
-			}
-			_ = questionnaire.AskAboutGitContext(&installCmdOptions, contexts)
-			_ = questionnaire.AskAboutGitRepo(&installCmdOptions)
-			if installCmdOptions.Git.RepoUrl != "" {
-				logger.Info(fmt.Sprint("Creating repositories..."))
-				err = argoApi.Repository().CreateRepository(argoSdk.CreateRepositoryOpt{
-					Repo:     installCmdOptions.Git.RepoUrl,
-					Username: installCmdOptions.Git.Auth.Pass,
-					Password: installCmdOptions.Git.Auth.Pass,
-				})
+	addManifestRepo, err := questionnaire.AskAboutManifestRepo(prompter, &installCmdOptions)
+	if err != nil {
+		return failInstallation(err.Error())
+	}
+	addCredsTemplate := installCmdOptions.Git.CredsTemplateUrl != "" && !checkpoints.IsCompleted(checkpoint.StepRepoCreds)
+	var knownHostsEntries, knownHosts []string
+	if addManifestRepo || addCredsTemplate {
+		// git context provides credentials of both the manifest repo and the credentials template
+		contexts, err := git.GetAvailableContexts(installCmdOptions.Codefresh.Host, installCmdOptions.Codefresh.Auth.Token)
+		if err != nil {
+			return failInstallation(fmt.Sprintf("Can't get git contexts: \"%s\"", err.Error()))
+		}
+		err = questionnaire.AskAboutGitContext(prompter, &installCmdOptions, contexts)
+		if err != nil {
+			return failInstallation(err.Error())
+		}
+		knownHostsEntries, knownHosts, err = sshKnownHosts(kubeClient)
+		if err != nil {
+			return failInstallation(fmt.Sprintf("Can't get ssh known hosts: \"%s\"", err.Error()))
+		}
+	}
+	if addManifestRepo {
+		// git repo
+		err = questionnaire.AskAboutGitRepo(prompter, &installCmdOptions, knownHosts)
+		if err != nil {
+			return failInstallation(fmt.Sprintf("Can't get git repo url: \"%s\"", err.Error()))
+		}
+		if installCmdOptions.Git.RepoUrl != "" && !checkpoints.IsCompleted(checkpoint.StepRepo) {
+			logger.Info(fmt.Sprint("Creating repositories..."))
+			repoOptions := argoSdk.CreateRepositoryOpt{Repo: installCmdOptions.Git.RepoUrl}
+			if git.IsSshUrl(installCmdOptions.Git.RepoUrl) {
+				if installCmdOptions.Git.Auth.SshPrivateKey == "" {
+					return failInstallation(fmt.Sprintf("Can't add ssh repo \"%s\" without ssh private key", installCmdOptions.Git.RepoUrl))
+				}
+				err = configureKnownHosts(kubeClient, knownHostsEntries, knownHosts, installCmdOptions.Git.RepoUrl)
 				if err != nil {
-					// @todo - retry url passing
-					return failInstallation(fmt.Sprintf("Can't manage access to git repo: \"%s\"", err.Error()))
+					return failInstallation(fmt.Sprintf("Can't configure ssh known hosts: \"%s\"", err.Error()))
 				}
+				repoOptions.SshPrivateKey = installCmdOptions.Git.Auth.SshPrivateKey
+				err = argoApi.CreateRepository(repoOptions)
+			} else if installCmdOptions.Git.Auth.GithubApp.AppID != 0 {
+				err = argoApi.CreateGithubAppRepository(argo.GithubAppRepositoryOpt{
+					Repo:           installCmdOptions.Git.RepoUrl,
+					GithubAppCreds: githubAppCreds(installCmdOptions.Git.Auth.GithubApp),
+				})
+			} else {
+				repoOptions.Username = installCmdOptions.Git.Auth.Username
+				repoOptions.Password = installCmdOptions.Git.Auth.Pass
+				err = argoApi.CreateRepository(repoOptions)
+			}
+			if err != nil {
+				return failInstallation(fmt.Sprintf("Can't manage access to git repo: \"%s\"", err.Error()))
 			}
+			checkpoints.Complete(checkpoint.StepRepo, "")
+		}
+	}
 
+	if addCredsTemplate {
+		logger.Info(fmt.Sprint("Creating repository credentials template..."))
+		credsOptions := argo.RepoCredsOpt{Url: installCmdOptions.Git.CredsTemplateUrl}
+		if git.IsSshUrl(credsOptions.Url) {
+			err = configureKnownHosts(kubeClient, knownHostsEntries, knownHosts, credsOptions.Url)
+			if err != nil {
+				return failInstallation(fmt.Sprintf("Can't configure ssh known hosts: \"%s\"", err.Error()))
+			}
+			credsOptions.SshPrivateKey = installCmdOptions.Git.Auth.SshPrivateKey
+		} else if installCmdOptions.Git.Auth.GithubApp.AppID != 0 {
+			credsOptions.GithubAppCreds = githubAppCreds(installCmdOptions.Git.Auth.GithubApp)
+		} else {
+			credsOptions.Username = installCmdOptions.Git.Auth.Username
+			credsOptions.Password = installCmdOptions.Git.Auth.Pass
+		}
+		err = argoApi.CreateRepoCreds(credsOptions)
+		if err != nil {
+			return failInstallation(fmt.Sprintf("Can't create repository credentials template: \"%s\"", err.Error()))
 		}
+		checkpoints.Complete(checkpoint.StepRepoCreds, "")
+	}
+
+	if argo.IsRbacConfigured(installCmdOptions.Rbac) && !checkpoints.IsCompleted(checkpoint.StepRbac) {
+		logger.Info(fmt.Sprint("Configure argocd rbac..."))
+		err = argo.ConfigureRbac(kubeClient, installCmdOptions.Rbac)
+		if err != nil {
+			return failInstallation(fmt.Sprintf("Can't configure rbac: \"%s\"", err.Error()))
+		}
+		checkpoints.Complete(checkpoint.StepRbac, "")
+	}
+
+	// port-forward host is localhost of this machine, it's not the url argocd is reached by
+	if installCmdOptions.Controller.Expose != install.ExposePortForward && !checkpoints.IsCompleted(checkpoint.StepUrl) {
+		logger.Info(fmt.Sprint("Configure argocd url..."))
+		err = argo.ConfigureUrl(kubeClient, argoHost)
+		if err != nil {
+			return failInstallation(fmt.Sprintf("Can't configure argocd url: \"%s\"", err.Error()))
+		}
+		checkpoints.Complete(checkpoint.StepUrl, "")
+	}
+
+	if argo.IsSsoConfigured(installCmdOptions.Sso) && !checkpoints.IsCompleted(checkpoint.StepSso) {
+		logger.Info(fmt.Sprint("Configure argocd sso..."))
+		if installCmdOptions.Controller.Expose == install.ExposePortForward {
+			logger.Warning(fmt.Sprint("Argocd url is not set with port-forward, sso requires url in argocd-cm once argocd is exposed"))
+		}
+		err = argo.ConfigureSso(kubeClient, installCmdOptions.Sso)
+		if err != nil {
+			return failInstallation(fmt.Sprintf("Can't configure sso: \"%s\"", err.Error()))
+		}
+		checkpoints.Complete(checkpoint.StepSso, "")
+	}
+
+	if len(installCmdOptions.Projects) > 0 && !checkpoints.IsCompleted(checkpoint.StepProjects) {
+		logger.Info(fmt.Sprint("Create argocd projects..."))
+		err = argo.ApplyProjects(kubeClient, argoApi, installCmdOptions.Kube.Namespace, installCmdOptions.Projects)
+		if err != nil {
+			return failInstallation(fmt.Sprintf("Can't create projects: \"%s\"", err.Error()))
+		}
+		checkpoints.Complete(checkpoint.StepProjects, "")
+	}
 
+	if !installCmdOptions.DefaultApp.Skip && !checkpoints.IsCompleted(checkpoint.StepDefaultApp) {
 		logger.Info(fmt.Sprint("Create default argocd app..."))
-		err = argo.CreateDefaultApp(&argoApi)
+		err = argo.ApplyApplication(kubeClient, installCmdOptions.Kube.Namespace, installCmdOptions.DefaultApp.ApplicationOptions)
 		if err != nil {
 			return failInstallation(fmt.Sprintf("Can't create default app: \"%s\"", err.Error()))
 		}
+		checkpoints.Complete(checkpoint.StepDefaultApp, "")
+	}
+
+	if installCmdOptions.Bootstrap.RepoUrl != "" && !checkpoints.IsCompleted(checkpoint.StepBootstrap) {
+		logger.Info(fmt.Sprint("Create bootstrap argocd app..."))
+		err = argo.CreateBootstrapApp(kubeClient, installCmdOptions.Kube.Namespace, installCmdOptions.Bootstrap)
+		if err != nil {
+			return failInstallation(fmt.Sprintf("Can't create bootstrap app: \"%s\"", err.Error()))
+		}
+		checkpoints.Complete(checkpoint.StepBootstrap, "")
+	}
+
+	if installCmdOptions.ClusterApplicationSet.RepoUrl != "" && !checkpoints.IsCompleted(checkpoint.StepAppSet) {
+		logger.Info(fmt.Sprint("Create application set for imported clusters..."))
+		err = argo.ApplyClusterApplicationSet(kubeClient, installCmdOptions.Kube.Namespace, installCmdOptions.ClusterApplicationSet)
+		if err != nil {
+			return failInstallation(fmt.Sprintf("Can't create application set: \"%s\"", err.Error()))
+		}
+		checkpoints.Complete(checkpoint.StepAppSet, "")
+	}
+
+	// agent account, new token is generated on each run as it's not kept by checkpoints
+	if installCmdOptions.Argo.AgentAccount != "" {
+		logger.Info(fmt.Sprint("Creating argocd account for agent..."))
+		err = argo.ConfigureAgentAccount(kubeClient, installCmdOptions.Argo.AgentAccount)
+		if err != nil {
+			return failInstallation(fmt.Sprintf("Can't create agent account: \"%s\"", err.Error()))
+		}
+		installCmdOptions.Argo.AgentToken, err = argo.CreateAgentToken(argoApi, installCmdOptions.Argo.AgentAccount)
+		if err != nil {
+			return failInstallation(fmt.Sprintf("Can't create agent token: \"%s\"", err.Error()))
+		}
+	}
 
+	if installCmdOptions.Installer.DryRun {
+		plan.Record(install.PlannedAction{Action: "install", Kind: "Agent", Name: agentVersion, Namespace: installCmdOptions.Kube.Namespace})
+		return plan.Render(cmd.OutOrStdout(), installCmdOptions.Installer.Output)
+	}
+
+	if !checkpoints.IsCompleted(checkpoint.StepAgent) {
 		logger.Info(fmt.Sprint("Install agent..."))
+		// recorded before the run, failed run can leave the agent partially installed
+		journal.Record("uninstall agent", func() error {
+			return agentUninstaller.New(initAgentUninstallOptions(&installCmdOptions)).Run()
+		})
 		err, _ = agentInstaller.Run(initAgentInstallOptions(&installCmdOptions))
 		if err != nil {
 			return failInstallation(fmt.Sprintf("Can't install argocd agent: \"%s\"", err.Error()))
 		}
+		checkpoints.Complete(checkpoint.StepAgent, "")
+	}
 
-		successMsg := fmt.Sprintf("Successfully installed codefresh gitops controller, host: %s", argoHost)
-		logger.Success(successMsg)
-		eventSender := cfEventSender.New(cfEventSender.EVENT_CONTROLLER_INSTALL)
-		eventSender.Success(successMsg)
-		return nil
-	},
+	// installation is finished, next run starts from scratch
+	checkpoints.Reset()
+
+	successMsg := fmt.Sprintf("Successfully installed codefresh gitops controller, host: %s", argoHost)
+	logger.Success(successMsg)
+	eventSender := cfEventSender.New(cfEventSender.EVENT_CONTROLLER_INSTALL)
+	eventSender.Success(successMsg)
+	return nil
 }
 
 func initAgentInstallOptions(installCmdOptions *install.CmdOptions) agentInstallPkg.InstallCmdOptions {
@@ -212,9 +582,17 @@ func initAgentInstallOptions(installCmdOptions *install.CmdOptions) agentInstall
 	agentInstallOptions.Agent.Version = agentVersion
 
 	agentInstallOptions.Argo.Host = installCmdOptions.Argo.Host
-	agentInstallOptions.Argo.Token = installCmdOptions.Argo.Token
-	agentInstallOptions.Argo.Username = installCmdOptions.Argo.Username
-	agentInstallOptions.Argo.Password = installCmdOptions.Argo.Password
+	if installCmdOptions.Argo.AgentHost != "" {
+		agentInstallOptions.Argo.Host = installCmdOptions.Argo.AgentHost
+	}
+	if installCmdOptions.Argo.AgentToken != "" {
+		// agent authenticates with api key of its own account only
+		agentInstallOptions.Argo.Token = installCmdOptions.Argo.AgentToken
+	} else {
+		agentInstallOptions.Argo.Token = installCmdOptions.Argo.Token
+		agentInstallOptions.Argo.Username = installCmdOptions.Argo.Username
+		agentInstallOptions.Argo.Password = installCmdOptions.Argo.Password
+	}
 
 	agentInstallOptions.Codefresh.Host = installCmdOptions.Codefresh.Host
 	agentInstallOptions.Codefresh.Token = installCmdOptions.Codefresh.Auth.Token
@@ -243,11 +621,20 @@ func init() {
 	flags.StringVar(&installCmdOptions.Codefresh.Host, "codefresh-host", "", "Codefresh host")
 	flags.StringVar(&installCmdOptions.Codefresh.Auth.Token, "codefresh-token", "", "Codefresh api token")
 	flags.StringArrayVar(&installCmdOptions.Codefresh.Clusters, "codefresh-clusters", make([]string, 0), "")
+	flags.VarPF(&optionalBool{&installCmdOptions.Codefresh.ImportClusters}, "import-clusters", "", "Integrate clusters from your account to argocd, asked when not set").NoOptDefVal = "true"
 
 	flags.StringVar(&installCmdOptions.Argo.Token, "argo-token", "", "")
 	flags.StringVar(&installCmdOptions.Argo.Host, "argo-host", "", "")
+	flags.StringVar(&installCmdOptions.Argo.AgentAccount, "agent-account", "", "Argocd local account created for the agent with read and sync only role, e.g. codefresh-agent (default is admin credentials passed to the agent)")
+	flags.StringVar(&installCmdOptions.Argo.AgentHost, "argo-agent-host", "", "Argocd host used by the agent (default is argo host, in-cluster service address with --expose=port-forward)")
 	flags.StringVar(&installCmdOptions.Argo.Username, "argo-username", "admin", "")
 	flags.StringVar(&installCmdOptions.Argo.Password, "argo-password", "", "Set password for admin user of new argocd installation")
+	flags.BoolVar(&installCmdOptions.Argo.GeneratePassword, "generate-password", false, "Generate a strong random password for admin user")
+	flags.StringVar(&installCmdOptions.Argo.PasswordSecret, "password-secret", "", "Name of the secret to store admin password in (default is "+generatedPasswordSecret+" with --generate-password)")
+	flags.IntVar(&installCmdOptions.Argo.PasswordPolicy.MinLength, "password-min-length", 8, "Minimal length of admin password")
+	flags.BoolVar(&installCmdOptions.Argo.PasswordPolicy.RequireComplexity, "password-complexity", false, "Require lower and upper case letters and digits in admin password")
+	flags.BoolVar(&installCmdOptions.Argo.DeclarativePassword, "declarative-password", false, "Set admin password by writing its bcrypt hash into argocd-secret before argocd starts")
+	flags.BoolVar(&installCmdOptions.Argo.DeleteInitialPassword, "delete-initial-password", false, "Delete argocd-initial-admin-secret after the admin password is changed")
 
 	flags.StringVar(&installCmdOptions.Kube.Namespace, "kube-namespace", "argocd", "Namespace in Kubernetes cluster")
 	flags.StringVar(&installCmdOptions.Kube.ManifestPath, "install-manifest", "", "Url of argocd install manifest")
@@ -267,15 +654,226 @@ func init() {
 
 	flags.StringVar(&installCmdOptions.Git.Integration, "git-integration", "", "Name of git integration in Codefresh")
 	flags.StringVar(&installCmdOptions.Git.RepoUrl, "git-repo-url", "", "Url to git manifest repo")
+	flags.VarPF(&optionalBool{&installCmdOptions.Git.AddRepo}, "add-repo", "", "Integrate git context for manifest repo to argocd, asked when not set").NoOptDefVal = "true"
+	flags.BoolVar(&installCmdOptions.Git.SkipRepoCheck, "skip-repo-check", false, "Don't check the manifest repo is accessible before adding it to argocd")
+	flags.StringVar(&installCmdOptions.Git.CredsTemplateUrl, "git-creds-template-url", "", "Url prefix, e.g. https://github.com/my-org, every repo under it gets credentials of git integration")
+	githubApp := &installCmdOptions.Git.Auth.GithubApp
+	flags.Int64Var(&githubApp.AppID, "github-app-id", 0, "Github app id for manifest repos (default is github app git integration)")
+	flags.Int64Var(&githubApp.InstallationID, "github-app-installation-id", 0, "Github app installation id")
+	flags.StringVar(&githubApp.PrivateKeyFile, "github-app-private-key-file", "", "Path to github app private key")
+	flags.StringVar(&githubApp.EnterpriseBaseUrl, "github-app-enterprise-base-url", "", "Api url of github enterprise, e.g. https://github.example.com/api/v3")
+	flags.StringVar(&installCmdOptions.Git.SshKeyFile, "git-ssh-key-file", "", "Path to ssh private key for git@ manifest repo url (default is the key of git integration)")
+	flags.StringVar(&installCmdOptions.Git.KnownHostsFile, "ssh-known-hosts-file", "", "Path to ssh_known_hosts file added to argocd known hosts")
+	flags.StringArrayVar(&installCmdOptions.Git.HostFingerprints, "ssh-host-fingerprint", []string{}, "Pinned git server host key as host=SHA256:..., matching key is added to argocd known hosts")
 
 	flags.StringVar(&installCmdOptions.Host.HttpProxy, "http-proxy", "", "Http proxy")
 	flags.StringVar(&installCmdOptions.Host.HttpsProxy, "https-proxy", "", "Https proxy")
 
-	flags.BoolVar(&installCmdOptions.Controller.LoadBalancer, "load-balancer", true, "Setup load balancer")
+	flags.BoolVar(&installCmdOptions.Controller.LoadBalancer, "load-balancer", false, "Setup load balancer, same as --expose=loadbalancer")
+	_ = flags.MarkDeprecated("load-balancer", "use --expose=loadbalancer instead")
+	flags.StringVar(&installCmdOptions.Controller.Expose, "expose", "", "How to expose argocd server: loadbalancer|ingress|nodeport|port-forward|none")
+	flags.IntVar(&installCmdOptions.Controller.PortForward.LocalPort, "port-forward-port", 0, "Local port for --expose=port-forward (default is random port)")
+	flags.StringVar(&installCmdOptions.Controller.Ingress.Host, "ingress-host", "", "Host of argocd server ingress")
+	flags.StringVar(&installCmdOptions.Controller.Ingress.TlsSecret, "ingress-tls-secret", "", "Name of the secret with TLS certificate for argocd server ingress")
+	flags.StringVar(&installCmdOptions.Controller.Ingress.Class, "ingress-class", "", "Ingress class of argocd server ingress, networking.k8s.io/v1 ingress (kubernetes 1.19+) gets spec.ingressClassName, v1beta1 ingress (kubernetes 1.14 - 1.18) gets kubernetes.io/ingress.class annotation")
+	flags.StringToStringVar(&installCmdOptions.Controller.Ingress.Annotations, "ingress-annotation", map[string]string{}, "Annotations of argocd server ingress (default is nginx ssl-passthrough)")
+
+	defaultApp := &installCmdOptions.DefaultApp
+	flags.BoolVar(&defaultApp.Skip, "no-default-app", false, "Don't create default argocd application")
+	flags.StringVar(&defaultApp.Name, "default-app-name", "default", "Name of default argocd application")
+	flags.StringVar(&defaultApp.Project, "default-app-project", "default", "Project of default argocd application")
+	flags.StringVar(&defaultApp.RepoUrl, "default-app-repo", "https://github.com/argoproj/argocd-example-apps.git", "Git repo of default argocd application")
+	flags.StringVar(&defaultApp.Path, "default-app-path", "guestbook", "Path in git repo of default argocd application")
+	flags.StringVar(&defaultApp.Revision, "default-app-revision", "HEAD", "Git revision of default argocd application")
+	flags.StringVar(&defaultApp.DestinationServer, "default-app-dest-server", "https://kubernetes.default.svc", "Destination cluster of default argocd application")
+	flags.StringVar(&defaultApp.DestinationNamespace, "default-app-dest-namespace", "default", "Destination namespace of default argocd application")
+	flags.StringVar(&defaultApp.SyncPolicy, "default-app-sync-policy", install.SyncPolicyManual, "Sync policy of default argocd application: manual|automated")
+	flags.BoolVar(&defaultApp.Prune, "default-app-prune", false, "Prune resources of default argocd application with automated sync")
+	flags.BoolVar(&defaultApp.SelfHeal, "default-app-self-heal", false, "Self heal default argocd application with automated sync")
+
+	flags.StringVar(&installCmdOptions.Rbac.PolicyFile, "rbac-policy-file", "", "Path to argocd rbac policy csv, it is merged into argocd-rbac-cm")
+	flags.StringVar(&installCmdOptions.Rbac.DefaultRole, "rbac-default-role", "", "Default argocd role, e.g. role:readonly")
+	flags.StringSliceVar(&installCmdOptions.Rbac.Scopes, "rbac-scopes", []string{}, "OIDC scopes argocd rbac policy is matched against, e.g. groups,email")
+
+	oidc := &installCmdOptions.Sso.Oidc
+	flags.StringVar(&oidc.Name, "oidc-name", "", "Name of OIDC provider shown on argocd login page")
+	flags.StringVar(&oidc.Issuer, "oidc-issuer", "", "OIDC issuer url")
+	flags.StringVar(&oidc.ClientID, "oidc-client-id", "", "OIDC client id")
+	flags.StringVar(&oidc.ClientSecret, "oidc-client-secret", "", "OIDC client secret, it is stored in argocd-secret")
+	flags.StringVar(&oidc.ClientSecretRef, "oidc-client-secret-ref", "", "Reference to OIDC client secret in existing secret as <secret>:<key>")
+	flags.StringSliceVar(&oidc.RequestedScopes, "oidc-scopes", []string{}, "OIDC scopes requested in addition to openid, e.g. profile,email,groups")
+	flags.StringVar(&oidc.GroupsClaim, "oidc-groups-claim", "", "Name of ID token claim with user groups")
+	flags.StringVar(&installCmdOptions.Sso.DexConfigFile, "dex-config-file", "", "Path to dex.config yaml with dex connectors")
+
+	flags.StringVar(&installCmdOptions.Bootstrap.RepoUrl, "bootstrap-repo", "", "Git repo of the root application (app-of-apps) which manages the cluster")
+	flags.StringVar(&installCmdOptions.Bootstrap.Path, "bootstrap-path", ".", "Path in bootstrap repo with argocd applications")
+	flags.StringVar(&installCmdOptions.Bootstrap.Revision, "bootstrap-revision", "HEAD", "Git revision of bootstrap repo")
+	flags.StringVar(&installCmdOptions.Bootstrap.Name, "bootstrap-app-name", "root", "Name of the root application")
+	flags.StringVar(&installCmdOptions.Bootstrap.Project, "bootstrap-project", "default", "Project of the root application")
+
+	appSet := &installCmdOptions.ClusterApplicationSet
+	flags.StringVar(&appSet.RepoUrl, "cluster-appset-repo", "", "Git repo of the application rolled out to every imported cluster with ApplicationSet")
+	flags.StringVar(&appSet.Name, "cluster-appset-name", "baseline", "Name of the ApplicationSet, applications are named <cluster>-<name>")
+	flags.StringVar(&appSet.Project, "cluster-appset-project", "default", "Project of the imported clusters applications")
+	flags.StringVar(&appSet.Path, "cluster-appset-path", ".", "Path in git repo of the imported clusters applications")
+	flags.StringVar(&appSet.Revision, "cluster-appset-revision", "HEAD", "Git revision of the imported clusters applications")
+	flags.StringVar(&appSet.DestinationNamespace, "cluster-appset-dest-namespace", "default", "Destination namespace of the imported clusters applications")
+	flags.StringVar(&appSet.SyncPolicy, "cluster-appset-sync-policy", install.SyncPolicyAutomated, "Sync policy of the imported clusters applications: manual|automated")
+	flags.BoolVar(&appSet.Prune, "cluster-appset-prune", false, "Prune resources of the imported clusters applications with automated sync")
+	flags.BoolVar(&appSet.SelfHeal, "cluster-appset-self-heal", false, "Self heal the imported clusters applications with automated sync")
+
+	flags.BoolVar(&installCmdOptions.Installer.DryRun, "dry-run", false, "Print the installation plan without changing the cluster")
+	flags.StringVar(&installCmdOptions.Installer.Output, "output", "text", "Format of the dry run plan: text|json")
+	flags.BoolVar(&installCmdOptions.Installer.Restart, "restart", false, "Ignore checkpoints of previous failed installation and start from scratch")
+	flags.DurationVar(&installCmdOptions.Installer.WaitTimeout, "wait-timeout", 5*time.Minute, "How long to wait for argocd workloads to become ready")
+	flags.BoolVar(&installCmdOptions.Installer.KeepOnFailure, "keep-on-failure", false, "Don't roll back failed installation, it can be resumed by the next run")
+
+	flags.StringVar(&installCmdOptions.Questionnaire.AnswersFile, "values", "", "Path to yaml file with answers for the installation questions, --answers is an alias")
+	flags.SetNormalizeFunc(answersAlias)
+	flags.BoolVarP(&installCmdOptions.Questionnaire.Yes, "yes", "y", false, "Accept default answers instead of prompting, clusters import and manifest repo are skipped unless answered")
+
+}
+
+// askAboutArgoPassword resolves the admin password, generated password of the resumed
+// installation is read back from the password secret
+func askAboutArgoPassword(prompter questionnaire.Prompter, kubeClient kube.Kube, checkpoints *checkpoint.Checkpoints) error {
+	argoOptions := &installCmdOptions.Argo
+	if argoOptions.Password == "" && argoOptions.GeneratePassword && checkpoints.IsCompleted(checkpoint.StepPassword) {
+		data, err := kubeClient.GetSecretData(argoOptions.PasswordSecret)
+		if err != nil {
+			return err
+		}
+		argoOptions.Password = data["password"]
+		return nil
+	}
+	return questionnaire.AskAboutPass(prompter, &installCmdOptions)
+}
+
+// storeArgoPassword saves the admin password into the password secret, it's always set for generated password
+func storeArgoPassword(kubeClient kube.Kube, options *install.CmdOptions) error {
+	argoOptions := options.Argo
+	if argoOptions.PasswordSecret == "" {
+		return nil
+	}
+	logger.Info(fmt.Sprintf("Storing admin password in secret \"%s\"...", argoOptions.PasswordSecret))
+	return kubeClient.SaveSecretData(argoOptions.PasswordSecret, map[string]string{
+		"username": argoOptions.Username,
+		"password": argoOptions.Password,
+	})
+}
+
+func githubAppCreds(githubApp install.GithubAppOptions) argo.GithubAppCreds {
+	return argo.GithubAppCreds{
+		GithubAppID:                githubApp.AppID,
+		GithubAppInstallationID:    githubApp.InstallationID,
+		GithubAppPrivateKey:        githubApp.PrivateKey,
+		GithubAppEnterpriseBaseUrl: githubApp.EnterpriseBaseUrl,
+	}
+}
+
+// sshKnownHosts returns the entries of the known hosts file and of the scanned pinned host keys,
+// and the same entries merged with argocd known hosts
+func sshKnownHosts(kubeClient kube.Kube) ([]string, []string, error) {
+	entries, err := git.KnownHostsEntries(installCmdOptions.Git.KnownHostsFile, installCmdOptions.Git.HostFingerprints)
+	if err != nil {
+		return nil, nil, err
+	}
+	argoKnownHosts, err := argo.KnownHosts(kubeClient)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entries, append(argoKnownHosts, entries...), nil
+}
+
+// configureKnownHosts adds the entries to argocd known hosts, host key verification stays on,
+// so the repo host must be in the known hosts
+func configureKnownHosts(kubeClient kube.Kube, entries []string, knownHosts []string, repoUrl string) error {
+	host, err := git.SshHost(repoUrl)
+	if err != nil {
+		return err
+	}
+	known, err := git.IsKnownHost(knownHosts, host)
+	if err != nil {
+		return err
+	}
+	if !known {
+		return errors.New(fmt.Sprintf("Host \"%s\" is not in argocd known hosts, use --ssh-known-hosts-file or --ssh-host-fingerprint", host))
+	}
+	return argo.AddKnownHosts(kubeClient, entries)
+}
+
+// optionalBool is bool flag of the answer which is asked when the flag is not set
+type optionalBool struct {
+	value **bool
+}
+
+func (b *optionalBool) Set(s string) error {
+	value, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*b.value = &value
+	return nil
+}
+
+func (b *optionalBool) String() string {
+	if *b.value == nil {
+		return ""
+	}
+	return strconv.FormatBool(**b.value)
+}
+
+func (b *optionalBool) Type() string {
+	return "bool"
+}
+
+// answersAlias makes --answers the same flag as --values
+func answersAlias(f *pflag.FlagSet, name string) pflag.NormalizedName {
+	if name == "answers" {
+		name = "values"
+	}
+	return pflag.NormalizedName(name)
+}
+
+func newPrompter(options *install.CmdOptions) questionnaire.Prompter {
+	if options.Questionnaire.Yes {
+		return questionnaire.NewDefaultsPrompter()
+	}
+	if !questionnaire.IsInteractive() {
+		return questionnaire.NewNonInteractivePrompter()
+	}
+	return questionnaire.NewTerminalPrompter()
+}
 
+// loadAnswersFile applies the answers file, then the command line is parsed again over it,
+// so flags passed explicitly take precedence over the file
+func loadAnswersFile(cmd *cobra.Command, options *install.CmdOptions) error {
+	if options.Questionnaire.AnswersFile == "" {
+		return nil
+	}
+	err := install.LoadAnswers(options.Questionnaire.AnswersFile, options)
+	if err != nil {
+		return err
+	}
+
+	flags := pflag.NewFlagSet(cmd.Name(), pflag.ContinueOnError)
+	// errors and deprecation warnings of the command line were printed by the first parse
+	flags.SetOutput(ioutil.Discard)
+	flags.SetNormalizeFunc(cmd.Flags().GetNormalizeFunc())
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		if sliceValue, ok := flag.Value.(pflag.SliceValue); ok && flag.Changed {
+			// parsed slice flag appends its values, they replace the answer of the file
+			_ = sliceValue.Replace(nil)
+		}
+		flags.AddFlag(flag)
+	})
+	return flags.Parse(os.Args[1:])
 }
 
 func failInstallation(msg string) error {
+	if installCmdOptions.Installer.DryRun {
+		return errors.New(msg)
+	}
 	eventSender := cfEventSender.New(cfEventSender.EVENT_CONTROLLER_INSTALL)
 	eventSender.Fail(msg)
 	return errors.New(msg)
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	argoSdk "github.com/codefresh-io/argocd-sdk/pkg/api"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/argo"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/logger"
+	"github.com/spf13/cobra"
+)
+
+var repoCredsCmdOptions = install.CmdOptions{}
+
+var repoCredsCmd = &cobra.Command{
+	Use:   "repo-creds",
+	Short: "Manage argocd repository credentials templates",
+	Long:  `Manage argocd repository credentials templates`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
+	},
+}
+
+var repoCredsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List repository credentials templates",
+	Long:  `List repository credentials templates`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		argoApi, err := newRepoCredsArgoApi()
+		if err != nil {
+			return err
+		}
+		creds, err := argoApi.GetRepoCreds()
+		if err != nil {
+			return errors.New(fmt.Sprintf("Can't get repository credentials templates: \"%s\"", err.Error()))
+		}
+		if len(creds) == 0 {
+			logger.Info(fmt.Sprint("There are no repository credentials templates"))
+			return nil
+		}
+		for _, item := range creds {
+			logger.Info(fmt.Sprintf("%s\t%s", item.Url, item.Username))
+		}
+		return nil
+	},
+}
+
+var repoCredsRemoveCmd = &cobra.Command{
+	Use:   "remove <url>",
+	Short: "Remove repository credentials template",
+	Long:  `Remove repository credentials template`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		argoApi, err := newRepoCredsArgoApi()
+		if err != nil {
+			return err
+		}
+		err = argoApi.DeleteRepoCreds(args[0])
+		if err != nil {
+			return errors.New(fmt.Sprintf("Can't remove repository credentials template: \"%s\"", err.Error()))
+		}
+		logger.Success(fmt.Sprintf("Repository credentials template \"%s\" removed", args[0]))
+		return nil
+	},
+}
+
+func newRepoCredsArgoApi() (argo.Api, error) {
+	argoOptions := repoCredsCmdOptions.Argo
+	if argoOptions.Host == "" {
+		return nil, errors.New(fmt.Sprint("Argocd host is required, use --argo-host"))
+	}
+	token := argoOptions.Token
+	if token == "" {
+		var err error
+		token, err = argoSdk.GetToken(argoOptions.Username, argoOptions.Password, argoOptions.Host)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Can't get argo token: \"%s\"", err.Error()))
+		}
+	}
+	return argo.New(argoOptions.Host, token, nil), nil
+}
+
+func init() {
+	rootCmd.AddCommand(repoCredsCmd)
+	repoCredsCmd.AddCommand(repoCredsListCmd)
+	repoCredsCmd.AddCommand(repoCredsRemoveCmd)
+	flags := repoCredsCmd.PersistentFlags()
+
+	flags.StringVar(&repoCredsCmdOptions.Argo.Host, "argo-host", "", "Argocd url")
+	flags.StringVar(&repoCredsCmdOptions.Argo.Token, "argo-token", "", "Argocd token, username and password are used when it is empty")
+	flags.StringVar(&repoCredsCmdOptions.Argo.Username, "argo-username", "admin", "Argocd username")
+	flags.StringVar(&repoCredsCmdOptions.Argo.Password, "argo-password", "", "Argocd password")
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/kube"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/logger"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/questionnaire"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"os"
+	"os/user"
+	"path"
+)
+
+var setPasswordCmdOptions = install.CmdOptions{}
+
+var setPasswordCmd = &cobra.Command{
+	Use:   "set-password",
+	Short: "Set argocd admin password",
+	Long:  `Set argocd admin password by writing its bcrypt hash into argocd-secret, the current password is not required`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		prompter := newPrompter(&setPasswordCmdOptions)
+
+		err := questionnaire.AskAboutKubeContext(prompter, &setPasswordCmdOptions)
+		if err != nil {
+			return errors.New(fmt.Sprintf("Can't get kube context: \"%s\"", err.Error()))
+		}
+		kubeOptions := setPasswordCmdOptions.Kube
+		kubeClient, err := kube.New(&kube.Options{
+			ContextName:      kubeOptions.Context,
+			Namespace:        kubeOptions.Namespace,
+			PathToKubeConfig: kubeOptions.ConfigPath,
+			InCluster:        kubeOptions.InCluster,
+		})
+		if err != nil {
+			return errors.New(fmt.Sprintf("Can't create kube client: \"%s\"", err.Error()))
+		}
+
+		err = questionnaire.AskAboutNamespace(prompter, &setPasswordCmdOptions, kubeClient)
+		if err != nil {
+			return errors.New(fmt.Sprintf("Can't get namespace: \"%s\"", err.Error()))
+		}
+		kubeClient.UseNamespace(setPasswordCmdOptions.Kube.Namespace)
+
+		if setPasswordCmdOptions.Argo.GeneratePassword && setPasswordCmdOptions.Argo.PasswordSecret == "" {
+			setPasswordCmdOptions.Argo.PasswordSecret = generatedPasswordSecret
+		}
+		err = questionnaire.AskAboutPass(prompter, &setPasswordCmdOptions)
+		if err != nil {
+			return errors.New(fmt.Sprintf("Can't get argo password: \"%s\"", err.Error()))
+		}
+
+		err = kubeClient.SetAdminPassword(setPasswordCmdOptions.Argo.Password)
+		if err != nil {
+			return errors.New(fmt.Sprintf("Can't set admin password: \"%s\"", err.Error()))
+		}
+		// argocd-secret holds the password of the admin user only
+		setPasswordCmdOptions.Argo.Username = "admin"
+		err = storeArgoPassword(kubeClient, &setPasswordCmdOptions)
+		if err != nil {
+			return errors.New(fmt.Sprintf("Can't store argo password: \"%s\"", err.Error()))
+		}
+		logger.Success(fmt.Sprintf("Argocd admin password updated in namespace \"%s\"", setPasswordCmdOptions.Kube.Namespace))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(setPasswordCmd)
+	flags := setPasswordCmd.Flags()
+
+	flags.StringVar(&setPasswordCmdOptions.Argo.Password, "argo-password", "", "New password for admin user")
+	flags.BoolVar(&setPasswordCmdOptions.Argo.GeneratePassword, "generate-password", false, "Generate a strong random password for admin user")
+	flags.StringVar(&setPasswordCmdOptions.Argo.PasswordSecret, "password-secret", "", "Name of the secret to store admin password in (default is "+generatedPasswordSecret+" with --generate-password)")
+	flags.IntVar(&setPasswordCmdOptions.Argo.PasswordPolicy.MinLength, "password-min-length", 8, "Minimal length of admin password")
+	flags.BoolVar(&setPasswordCmdOptions.Argo.PasswordPolicy.RequireComplexity, "password-complexity", false, "Require lower and upper case letters and digits in admin password")
+	flags.StringVar(&setPasswordCmdOptions.Kube.Namespace, "kube-namespace", viper.GetString("kube-namespace"), "Namespace of argocd installation")
+	flags.BoolVar(&setPasswordCmdOptions.Kube.InCluster, "in-cluster", false, "Set flag if command is running from inside a cluster")
+
+	var kubeConfigPath string
+	currentUser, _ := user.Current()
+	if currentUser != nil {
+		kubeConfigPath = os.Getenv("KUBECONFIG")
+		if kubeConfigPath == "" {
+			kubeConfigPath = path.Join(currentUser.HomeDir, ".kube", "config")
+		}
+	}
+
+	flags.StringVar(&setPasswordCmdOptions.Kube.Context, "kube-context-name", viper.GetString("kube-context"), "Name of the kubernetes context of argocd installation (default is current-context) [$KUBE_CONTEXT]")
+	flags.StringVar(&setPasswordCmdOptions.Kube.ConfigPath, "kubeconfig", kubeConfigPath, "Path to kubeconfig file (default is $HOME/.kube/config)")
+}
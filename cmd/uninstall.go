@@ -4,11 +4,11 @@ import (
 	"errors"
 	"fmt"
 	cfEventSender "github.com/codefresh-io/argocd-listener/installer/pkg/cfeventsender"
-	"github.com/codefresh-io/argocd-listener/installer/pkg/logger"
 	agentUninstallPkg "github.com/codefresh-io/argocd-listener/installer/pkg/uninstall"
 	agentUninstaller "github.com/codefresh-io/argocd-listener/installer/pkg/uninstall/handler"
 	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
 	"github.com/codefresh-io/cf-gitops-controller/pkg/kube"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/logger"
 	"github.com/codefresh-io/cf-gitops-controller/pkg/questionnaire"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -25,7 +25,7 @@ var uninstallCmd = &cobra.Command{
 	Long:  `Uninstall gitops codefresh`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 
-		_ = questionnaire.AskAboutKubeContext(&uninstallCmdOptions)
+		_ = questionnaire.AskAboutKubeContext(questionnaire.NewTerminalPrompter(), &uninstallCmdOptions)
 		kubeOptions := uninstallCmdOptions.Kube
 		kubeClient, err := kube.New(&kube.Options{
 			ContextName:      kubeOptions.Context,
@@ -37,7 +37,7 @@ var uninstallCmd = &cobra.Command{
 			return failUninstall(fmt.Sprintf("Can't create kube client: \"%s\"", err.Error()))
 		}
 
-		_ = questionnaire.AskAboutNamespace(&uninstallCmdOptions, kubeClient)
+		_ = questionnaire.AskAboutNamespace(questionnaire.NewTerminalPrompter(), &uninstallCmdOptions, kubeClient)
 		_ = kubeClient.CreateNamespace(uninstallCmdOptions.Kube.Namespace)
 
 		_ = questionnaire.AskAboutManifest(&uninstallCmdOptions)
@@ -2,11 +2,11 @@ package cmd
 
 import (
 	"fmt"
-	"github.com/codefresh-io/argocd-listener/installer/pkg/logger"
 	agentUpdatePkg "github.com/codefresh-io/argocd-listener/installer/pkg/update"
 	agentUpdater "github.com/codefresh-io/argocd-listener/installer/pkg/update/handler"
 	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
 	"github.com/codefresh-io/cf-gitops-controller/pkg/kube"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/logger"
 	"github.com/codefresh-io/cf-gitops-controller/pkg/questionnaire"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -22,7 +22,7 @@ var updateCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		_ = questionnaire.AskAboutCodefreshCredentials(&installCmdOptions)
 		// kube context
-		_ = questionnaire.AskAboutKubeContext(&installCmdOptions)
+		_ = questionnaire.AskAboutKubeContext(questionnaire.NewTerminalPrompter(), &installCmdOptions)
 		kubeOptions := installCmdOptions.Kube
 		kubeClient, err := kube.New(&kube.Options{
 			ContextName:      kubeOptions.Context,
@@ -34,7 +34,7 @@ var updateCmd = &cobra.Command{
 		}
 
 		// namespace
-		_ = questionnaire.AskAboutNamespace(&installCmdOptions, kubeClient)
+		_ = questionnaire.AskAboutNamespace(questionnaire.NewTerminalPrompter(), &installCmdOptions, kubeClient)
 		err = kubeClient.CreateNamespace(installCmdOptions.Kube.Namespace)
 		if err != nil {
 			return failInstallation(fmt.Sprintf("Can't create namespace %s: \"%s\"", installCmdOptions.Kube.Namespace, err.Error()))
@@ -0,0 +1,102 @@
+package argo
+
+import (
+	"fmt"
+	"github.com/avast/retry-go"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/kube"
+	"strings"
+	"time"
+)
+
+const (
+	// ConfigMapName is argocd settings config map, it holds local accounts
+	ConfigMapName = "argocd-cm"
+	// RbacConfigMapName is argocd rbac config map
+	RbacConfigMapName = "argocd-rbac-cm"
+)
+
+// agentPermissions is what the agent needs to watch argocd resources and sync applications
+var agentPermissions = []string{
+	"applications, get, */*, allow",
+	"applications, sync, */*, allow",
+	"projects, get, *, allow",
+	"clusters, get, *, allow",
+	"repositories, get, *, allow",
+}
+
+// ConfigureAgentAccount creates local account with api key login and read/sync only role
+func ConfigureAgentAccount(kubeClient kube.Kube, account string) error {
+	settings, err := kubeClient.GetConfigMapData(ConfigMapName)
+	if err != nil {
+		return err
+	}
+	accountKey := fmt.Sprintf("accounts.%s", account)
+	if settings[accountKey] != "apiKey" {
+		settings = copyData(settings)
+		settings[accountKey] = "apiKey"
+		err = kubeClient.SaveConfigMapData(ConfigMapName, settings)
+		if err != nil {
+			return err
+		}
+	}
+
+	rbac, err := kubeClient.GetConfigMapData(RbacConfigMapName)
+	if err != nil {
+		return err
+	}
+	role := fmt.Sprintf("role:%s", account)
+	policy := []string{fmt.Sprintf("g, %s, %s", account, role)}
+	for _, permission := range agentPermissions {
+		policy = append(policy, fmt.Sprintf("p, %s, %s", role, permission))
+	}
+	policyCsv, changed := appendLines(rbac["policy.csv"], policy)
+	if !changed {
+		return nil
+	}
+	rbac = copyData(rbac)
+	rbac["policy.csv"] = policyCsv
+	return kubeClient.SaveConfigMapData(RbacConfigMapName, rbac)
+}
+
+// CreateAgentToken generates api key of the agent account, argocd-server needs a moment to load a new account
+func CreateAgentToken(argoApi Api, account string) (string, error) {
+	var token string
+	id := fmt.Sprintf("%s-%d", account, time.Now().Unix())
+	err := retry.Do(
+		func() error {
+			var err error
+			token, err = argoApi.CreateAccountToken(account, id)
+			return err
+		},
+		retry.Attempts(5),
+		retry.Delay(3*time.Second),
+	)
+	return token, err
+}
+
+func appendLines(policyCsv string, lines []string) (string, bool) {
+	existing := make(map[string]bool)
+	for _, line := range strings.Split(policyCsv, "\n") {
+		existing[strings.TrimSpace(line)] = true
+	}
+	changed := false
+	for _, line := range lines {
+		if existing[line] {
+			continue
+		}
+		if policyCsv != "" && !strings.HasSuffix(policyCsv, "\n") {
+			policyCsv += "\n"
+		}
+		policyCsv += line + "\n"
+		changed = true
+	}
+	return policyCsv, changed
+}
+
+func copyData(data map[string]string) map[string]string {
+	result := make(map[string]string, len(data))
+	for key, value := range data {
+		result[key] = value
+	}
+	return result
+}
@@ -0,0 +1,334 @@
+package argo
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	argoSdk "github.com/codefresh-io/argocd-sdk/pkg/api"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/rollback"
+	"net/http"
+	"net/url"
+)
+
+type (
+	// Api is the subset of argocd api which is used by the installation
+	Api interface {
+		SetToken(token string)
+		UpdatePassword(argoSdk.UpdatePasswordOpt) error
+		CreateCluster(argoSdk.ClusterOpt) error
+		DeleteCluster(server string) error
+		CreateRepository(argoSdk.CreateRepositoryOpt) error
+		DeleteRepository(repo string) error
+		CreateAccountToken(account string, id string) (string, error)
+		DeleteAccountToken(account string, id string) error
+		CreateProjectToken(project string, role string, id string) (string, error)
+		CreateGithubAppRepository(GithubAppRepositoryOpt) error
+		CreateRepoCreds(RepoCredsOpt) error
+		GetRepoCreds() ([]RepoCreds, error)
+		DeleteRepoCreds(credsUrl string) error
+	}
+
+	// RepoCredsOpt is argocd repository credential template, it applies to every repo under the url prefix
+	RepoCredsOpt struct {
+		Url           string `json:"url"`
+		Username      string `json:"username,omitempty"`
+		Password      string `json:"password,omitempty"`
+		SshPrivateKey string `json:"sshPrivateKey,omitempty"`
+		GithubAppCreds
+	}
+
+	// GithubAppRepositoryOpt is repository with github app credentials, the sdk doesn't support them
+	GithubAppRepositoryOpt struct {
+		Repo string `json:"repo"`
+		GithubAppCreds
+	}
+
+	GithubAppCreds struct {
+		GithubAppID                int64  `json:"githubAppID,omitempty"`
+		GithubAppInstallationID    int64  `json:"githubAppInstallationID,omitempty"`
+		GithubAppPrivateKey        string `json:"githubAppPrivateKey,omitempty"`
+		GithubAppEnterpriseBaseUrl string `json:"githubAppEnterpriseBaseUrl,omitempty"`
+	}
+
+	RepoCreds struct {
+		Url      string `json:"url"`
+		Username string `json:"username"`
+	}
+
+	api struct {
+		host    string
+		token   string
+		argo    argoSdk.Argo
+		client  *http.Client
+		journal *rollback.Journal
+	}
+
+	recorder struct {
+		plan *install.Plan
+	}
+)
+
+// New returns argocd api, every mutating call is recorded to the journal if it is given
+func New(host string, token string, journal *rollback.Journal) Api {
+	a := &api{
+		host:    host,
+		journal: journal,
+		client: &http.Client{
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+		},
+	}
+	a.SetToken(token)
+	return a
+}
+
+// NewRecorder returns Api which only records the calls into the plan
+func NewRecorder(plan *install.Plan) Api {
+	return &recorder{plan: plan}
+}
+
+// SetToken switches the api to the new session, e.g. after the password change
+func (a *api) SetToken(token string) {
+	a.token = token
+	a.argo = argoSdk.New(&argoSdk.ClientOptions{Auth: argoSdk.AuthOptions{Token: token}, Host: a.host})
+}
+
+func (a *api) UpdatePassword(opt argoSdk.UpdatePasswordOpt) error {
+	err := a.argo.Auth().UpdatePassword(opt)
+	if err != nil {
+		return err
+	}
+	a.journal.Record(fmt.Sprintf("restore password of \"%s\"", opt.UserName), func() error {
+		// argocd revokes the sessions of the user on the password change, so the current token is stale
+		token, err := argoSdk.GetToken(opt.UserName, opt.NewPassword, a.host)
+		if err != nil {
+			return err
+		}
+		argo := argoSdk.New(&argoSdk.ClientOptions{Auth: argoSdk.AuthOptions{Token: token}, Host: a.host})
+		return argo.Auth().UpdatePassword(argoSdk.UpdatePasswordOpt{
+			UserName:        opt.UserName,
+			CurrentPassword: opt.NewPassword,
+			NewPassword:     opt.CurrentPassword,
+		})
+	})
+	return nil
+}
+
+func (a *api) CreateCluster(opt argoSdk.ClusterOpt) error {
+	_, err := a.argo.Clusters().CreateCluster(opt)
+	if err != nil {
+		return err
+	}
+	a.journal.Record(fmt.Sprintf("delete cluster \"%s\"", opt.Name), func() error {
+		return a.DeleteCluster(opt.Server)
+	})
+	return nil
+}
+
+func (a *api) DeleteCluster(server string) error {
+	return a.delete("/api/v1/clusters/" + url.PathEscape(server))
+}
+
+func (a *api) CreateRepository(opt argoSdk.CreateRepositoryOpt) error {
+	err := a.argo.Repository().CreateRepository(opt)
+	if err != nil {
+		return err
+	}
+	a.journal.Record(fmt.Sprintf("delete repository \"%s\"", opt.Repo), func() error {
+		return a.DeleteRepository(opt.Repo)
+	})
+	return nil
+}
+
+func (a *api) DeleteRepository(repo string) error {
+	return a.delete("/api/v1/repositories/" + url.PathEscape(repo))
+}
+
+func (a *api) CreateAccountToken(account string, id string) (string, error) {
+	var result struct {
+		Token string `json:"token"`
+	}
+	err := a.post("/api/v1/account/"+url.PathEscape(account)+"/token", map[string]interface{}{"name": account, "id": id}, &result)
+	if err != nil {
+		return "", err
+	}
+	a.journal.Record(fmt.Sprintf("delete token \"%s\" of account \"%s\"", id, account), func() error {
+		return a.DeleteAccountToken(account, id)
+	})
+	return result.Token, nil
+}
+
+func (a *api) DeleteAccountToken(account string, id string) error {
+	return a.delete("/api/v1/account/" + url.PathEscape(account) + "/token/" + url.PathEscape(id))
+}
+
+// CreateProjectToken generates JWT token of the project role, tokens are removed together
+// with the project role on rollback
+func (a *api) CreateProjectToken(project string, role string, id string) (string, error) {
+	var result struct {
+		Token string `json:"token"`
+	}
+	path := "/api/v1/projects/" + url.PathEscape(project) + "/roles/" + url.PathEscape(role) + "/token"
+	err := a.post(path, map[string]interface{}{"project": project, "role": role, "id": id}, &result)
+	if err != nil {
+		return "", err
+	}
+	return result.Token, nil
+}
+
+func (a *api) CreateGithubAppRepository(opt GithubAppRepositoryOpt) error {
+	err := a.post("/api/v1/repositories", opt, nil)
+	if err != nil {
+		return err
+	}
+	a.journal.Record(fmt.Sprintf("delete repository \"%s\"", opt.Repo), func() error {
+		return a.DeleteRepository(opt.Repo)
+	})
+	return nil
+}
+
+func (a *api) CreateRepoCreds(opt RepoCredsOpt) error {
+	err := a.post("/api/v1/repocreds", opt, nil)
+	if err != nil {
+		return err
+	}
+	a.journal.Record(fmt.Sprintf("delete repository credentials \"%s\"", opt.Url), func() error {
+		return a.DeleteRepoCreds(opt.Url)
+	})
+	return nil
+}
+
+func (a *api) GetRepoCreds() ([]RepoCreds, error) {
+	var result struct {
+		Items []RepoCreds `json:"items"`
+	}
+	err := a.get("/api/v1/repocreds", &result)
+	return result.Items, err
+}
+
+func (a *api) DeleteRepoCreds(credsUrl string) error {
+	return a.delete("/api/v1/repocreds/" + url.PathEscape(credsUrl))
+}
+
+func (a *api) get(path string, result interface{}) error {
+	request, err := http.NewRequest("GET", a.host+path, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+a.token)
+	response, err := a.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return errors.New(response.Status)
+	}
+	return json.NewDecoder(response.Body).Decode(result)
+}
+
+func (a *api) post(path string, body interface{}, result interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest("POST", a.host+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+a.token)
+	request.Header.Set("Content-Type", "application/json")
+	response, err := a.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return errors.New(response.Status)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(response.Body).Decode(result)
+}
+
+func (a *api) delete(path string) error {
+	request, err := http.NewRequest("DELETE", a.host+path, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+a.token)
+	response, err := a.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusNotFound {
+		return errors.New(response.Status)
+	}
+	return nil
+}
+
+func (r *recorder) SetToken(token string) {
+}
+
+func (r *recorder) UpdatePassword(opt argoSdk.UpdatePasswordOpt) error {
+	r.plan.Record(install.PlannedAction{Action: "update", Kind: "Account", Name: opt.UserName, Details: "password"})
+	return nil
+}
+
+func (r *recorder) CreateCluster(opt argoSdk.ClusterOpt) error {
+	r.plan.Record(install.PlannedAction{Action: "create", Kind: "Cluster", Name: opt.Name, Details: opt.Server})
+	return nil
+}
+
+func (r *recorder) DeleteCluster(server string) error {
+	r.plan.Record(install.PlannedAction{Action: "delete", Kind: "Cluster", Name: server})
+	return nil
+}
+
+func (r *recorder) CreateRepository(opt argoSdk.CreateRepositoryOpt) error {
+	r.plan.Record(install.PlannedAction{Action: "create", Kind: "Repository", Name: opt.Repo})
+	return nil
+}
+
+func (r *recorder) DeleteRepository(repo string) error {
+	r.plan.Record(install.PlannedAction{Action: "delete", Kind: "Repository", Name: repo})
+	return nil
+}
+
+func (r *recorder) CreateAccountToken(account string, id string) (string, error) {
+	r.plan.Record(install.PlannedAction{Action: "create", Kind: "Token", Name: id, Details: "account: " + account})
+	return "<token>", nil
+}
+
+func (r *recorder) DeleteAccountToken(account string, id string) error {
+	r.plan.Record(install.PlannedAction{Action: "delete", Kind: "Token", Name: id, Details: "account: " + account})
+	return nil
+}
+
+func (r *recorder) CreateProjectToken(project string, role string, id string) (string, error) {
+	r.plan.Record(install.PlannedAction{Action: "create", Kind: "Token", Name: id, Details: fmt.Sprintf("project: %s, role: %s", project, role)})
+	return "<token>", nil
+}
+
+func (r *recorder) CreateGithubAppRepository(opt GithubAppRepositoryOpt) error {
+	r.plan.Record(install.PlannedAction{Action: "create", Kind: "Repository", Name: opt.Repo, Details: fmt.Sprintf("github app: %d", opt.GithubAppID)})
+	return nil
+}
+
+func (r *recorder) CreateRepoCreds(opt RepoCredsOpt) error {
+	r.plan.Record(install.PlannedAction{Action: "create", Kind: "RepositoryCredentials", Name: opt.Url})
+	return nil
+}
+
+func (r *recorder) GetRepoCreds() ([]RepoCreds, error) {
+	return []RepoCreds{}, nil
+}
+
+func (r *recorder) DeleteRepoCreds(credsUrl string) error {
+	r.plan.Record(install.PlannedAction{Action: "delete", Kind: "RepositoryCredentials", Name: credsUrl})
+	return nil
+}
@@ -0,0 +1,81 @@
+package argo
+
+import (
+	"errors"
+	"fmt"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/kube"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/logger"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ImportedClusterLabels are set on secrets of the clusters imported from codefresh
+var ImportedClusterLabels = map[string]string{
+	"gitops.codefresh.io/imported-from": "codefresh",
+}
+
+// NewClusterApplicationSet returns ApplicationSet which generates the application for every imported cluster,
+// application is named after the cluster and deployed to the destination namespace of the options
+func NewClusterApplicationSet(namespace string, options install.ApplicationOptions) (*unstructured.Unstructured, error) {
+	template, err := NewApplication(namespace, install.ApplicationOptions{
+		Name:                 "{{name}}-" + options.Name,
+		Project:              options.Project,
+		RepoUrl:              options.RepoUrl,
+		Path:                 options.Path,
+		Revision:             options.Revision,
+		DestinationServer:    "{{server}}",
+		DestinationNamespace: options.DestinationNamespace,
+		SyncPolicy:           options.SyncPolicy,
+		Prune:                options.Prune,
+		SelfHeal:             options.SelfHeal,
+	})
+	if err != nil {
+		return nil, err
+	}
+	templateMetadata := map[string]interface{}{"name": template.GetName()}
+	matchLabels := map[string]interface{}{}
+	for key, value := range ImportedClusterLabels {
+		matchLabels[key] = value
+	}
+
+	applicationSet := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "ApplicationSet",
+		"metadata": map[string]interface{}{
+			"name":      options.Name,
+			"namespace": namespace,
+		},
+		"spec": map[string]interface{}{
+			"generators": []interface{}{
+				map[string]interface{}{
+					"clusters": map[string]interface{}{
+						"selector": map[string]interface{}{"matchLabels": matchLabels},
+					},
+				},
+			},
+			"template": map[string]interface{}{
+				"metadata": templateMetadata,
+				"spec":     template.Object["spec"],
+			},
+		},
+	}}
+	return applicationSet, nil
+}
+
+// ApplyClusterApplicationSet creates or updates ApplicationSet for the imported clusters
+func ApplyClusterApplicationSet(kubeClient kube.Kube, namespace string, options install.ApplicationOptions) error {
+	applicationSet, err := NewClusterApplicationSet(namespace, options)
+	if err != nil {
+		return err
+	}
+	result, err := kubeClient.ApplyObject(applicationSet)
+	if meta.IsNoMatchError(err) {
+		return errors.New(fmt.Sprint("ApplicationSet resource is not found, make sure ApplicationSet controller is installed"))
+	}
+	if err != nil {
+		return err
+	}
+	logger.Info(fmt.Sprintf("ApplicationSet \"%s\" %s", options.Name, result))
+	return nil
+}
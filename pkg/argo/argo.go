@@ -1,18 +1,66 @@
 package argo
 
 import (
-	argoSdk "github.com/codefresh-io/argocd-sdk/pkg/api"
+	"errors"
+	"fmt"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/kube"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/logger"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
-func CreateDefaultApp(argoApi *argoSdk.Argo) error {
-	var requestOptions argoSdk.CreateApplicationOpt
-	requestOptions.Metadata.Name = "default"
-	requestOptions.Spec.Project = "default"
-	requestOptions.Spec.Destination.Name = ""
-	requestOptions.Spec.Destination.Namespace = "default"
-	requestOptions.Spec.Destination.Server = "https://kubernetes.default.svc"
-	requestOptions.Spec.Source.RepoURL = "https://github.com/argoproj/argocd-example-apps.git"
-	requestOptions.Spec.Source.Path = "guestbook"
-	requestOptions.Spec.Source.TargetRevision = "HEAD"
-	return (*argoApi).Application().CreateApplication(requestOptions)
+// NewApplication returns argocd Application resource, the sdk can't create applications with sync policy
+func NewApplication(namespace string, options install.ApplicationOptions) (*unstructured.Unstructured, error) {
+	if options.Name == "" || options.RepoUrl == "" {
+		return nil, errors.New(fmt.Sprint("Application name and repo url are required"))
+	}
+	spec := map[string]interface{}{
+		"project": options.Project,
+		"source": map[string]interface{}{
+			"repoURL":        options.RepoUrl,
+			"path":           options.Path,
+			"targetRevision": options.Revision,
+		},
+		"destination": map[string]interface{}{
+			"server":    options.DestinationServer,
+			"namespace": options.DestinationNamespace,
+		},
+	}
+	switch options.SyncPolicy {
+	case install.SyncPolicyAutomated:
+		spec["syncPolicy"] = map[string]interface{}{
+			"automated": map[string]interface{}{
+				"prune":    options.Prune,
+				"selfHeal": options.SelfHeal,
+			},
+		}
+	case install.SyncPolicyManual, "":
+	default:
+		return nil, errors.New(fmt.Sprintf("Unknown sync policy \"%s\", use manual or automated", options.SyncPolicy))
+	}
+
+	application := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Application",
+		"metadata": map[string]interface{}{
+			"name":      options.Name,
+			"namespace": namespace,
+		},
+		"spec": spec,
+	}}
+	return application, nil
+}
+
+// ApplyApplication creates the application, existing application is updated to the given options
+func ApplyApplication(kubeClient kube.Kube, namespace string, options install.ApplicationOptions) error {
+	application, err := NewApplication(namespace, options)
+	if err != nil {
+		return err
+	}
+	result, err := kubeClient.ApplyObject(application)
+	if err != nil {
+		return err
+	}
+	logger.Info(fmt.Sprintf("Application \"%s\" %s", options.Name, result))
+	return nil
 }
@@ -0,0 +1,26 @@
+package argo
+
+import (
+	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/kube"
+)
+
+// inClusterServer is the address of the cluster argocd is installed to
+const inClusterServer = "https://kubernetes.default.svc"
+
+// CreateBootstrapApp applies the root application (app-of-apps), it syncs applications
+// from the bootstrap path automatically, pruning and self healing them
+func CreateBootstrapApp(kubeClient kube.Kube, namespace string, options install.BootstrapOptions) error {
+	return ApplyApplication(kubeClient, namespace, install.ApplicationOptions{
+		Name:                 options.Name,
+		Project:              options.Project,
+		RepoUrl:              options.RepoUrl,
+		Path:                 options.Path,
+		Revision:             options.Revision,
+		DestinationServer:    inClusterServer,
+		DestinationNamespace: namespace,
+		SyncPolicy:           install.SyncPolicyAutomated,
+		Prune:                true,
+		SelfHeal:             true,
+	})
+}
@@ -0,0 +1,44 @@
+package argo
+
+import (
+	"github.com/codefresh-io/cf-gitops-controller/pkg/kube"
+	"strings"
+)
+
+// KnownHostsConfigMapName keeps ssh known hosts argocd verifies git servers with
+const KnownHostsConfigMapName = "argocd-ssh-known-hosts-cm"
+
+// AddKnownHosts merges the entries into argocd-ssh-known-hosts-cm, existing entries are kept
+func AddKnownHosts(kubeClient kube.Kube, entries []string) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	knownHosts, err := kubeClient.GetConfigMapData(KnownHostsConfigMapName)
+	if err != nil {
+		return err
+	}
+	sshKnownHosts, changed := appendLines(knownHosts["ssh_known_hosts"], entries)
+	if !changed {
+		return nil
+	}
+	knownHosts = copyData(knownHosts)
+	knownHosts["ssh_known_hosts"] = sshKnownHosts
+	return kubeClient.SaveConfigMapData(KnownHostsConfigMapName, knownHosts)
+}
+
+// KnownHosts returns the entries of argocd-ssh-known-hosts-cm
+func KnownHosts(kubeClient kube.Kube) ([]string, error) {
+	knownHosts, err := kubeClient.GetConfigMapData(KnownHostsConfigMapName)
+	if err != nil {
+		return nil, err
+	}
+	var entries []string
+	for _, line := range strings.Split(knownHosts["ssh_known_hosts"], "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, line)
+	}
+	return entries, nil
+}
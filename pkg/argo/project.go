@@ -0,0 +1,116 @@
+package argo
+
+import (
+	"errors"
+	"fmt"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/kube"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/logger"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"time"
+)
+
+// NewProject returns argocd AppProject resource
+func NewProject(namespace string, options install.ProjectOptions) (*unstructured.Unstructured, error) {
+	if options.Name == "" {
+		return nil, errors.New(fmt.Sprint("Project name is required"))
+	}
+
+	var sourceRepos []interface{}
+	for _, repo := range options.SourceRepos {
+		sourceRepos = append(sourceRepos, repo)
+	}
+	var destinations []interface{}
+	for _, destination := range options.Destinations {
+		destinations = append(destinations, map[string]interface{}{
+			"server":    destination.Server,
+			"namespace": destination.Namespace,
+		})
+	}
+	var clusterResources []interface{}
+	for _, resource := range options.ClusterResourceWhitelist {
+		clusterResources = append(clusterResources, map[string]interface{}{
+			"group": resource.Group,
+			"kind":  resource.Kind,
+		})
+	}
+	var roles []interface{}
+	for _, role := range options.Roles {
+		if role.Name == "" {
+			return nil, errors.New(fmt.Sprintf("Role name is required in project \"%s\"", options.Name))
+		}
+		var policies []interface{}
+		for _, policy := range role.Policies {
+			policies = append(policies, policy)
+		}
+		var groups []interface{}
+		for _, group := range role.Groups {
+			groups = append(groups, group)
+		}
+		roles = append(roles, map[string]interface{}{
+			"name":        role.Name,
+			"description": role.Description,
+			"policies":    policies,
+			"groups":      groups,
+		})
+	}
+
+	spec := map[string]interface{}{
+		"description":              options.Description,
+		"sourceRepos":              sourceRepos,
+		"destinations":             destinations,
+		"clusterResourceWhitelist": clusterResources,
+		"roles":                    roles,
+	}
+	for key, value := range spec {
+		if list, ok := value.([]interface{}); ok && list == nil {
+			delete(spec, key)
+		}
+	}
+
+	project := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "AppProject",
+		"metadata": map[string]interface{}{
+			"name":      options.Name,
+			"namespace": namespace,
+		},
+		"spec": spec,
+	}}
+	return project, nil
+}
+
+// ApplyProjects creates or updates the projects, JWT tokens of the roles are stored in their token secrets
+func ApplyProjects(kubeClient kube.Kube, argoApi Api, namespace string, projects []install.ProjectOptions) error {
+	for _, options := range projects {
+		project, err := NewProject(namespace, options)
+		if err != nil {
+			return err
+		}
+		result, err := kubeClient.ApplyObject(project)
+		if err != nil {
+			return err
+		}
+		logger.Info(fmt.Sprintf("Project \"%s\" %s", options.Name, result))
+
+		for _, role := range options.Roles {
+			if role.TokenSecret == "" {
+				continue
+			}
+			id := fmt.Sprintf("%s-%d", role.Name, time.Now().Unix())
+			token, err := argoApi.CreateProjectToken(options.Name, role.Name, id)
+			if err != nil {
+				return errors.New(fmt.Sprintf("Can't create token of role \"%s\" in project \"%s\": \"%s\"", role.Name, options.Name, err.Error()))
+			}
+			err = kubeClient.SaveSecretData(role.TokenSecret, map[string]string{
+				"project": options.Name,
+				"role":    role.Name,
+				"token":   token,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
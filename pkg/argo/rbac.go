@@ -0,0 +1,120 @@
+package argo
+
+import (
+	"errors"
+	"fmt"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/kube"
+	"io/ioutil"
+	"strings"
+)
+
+var rbacResources = map[string]bool{
+	"applications":    true,
+	"applicationsets": true,
+	"clusters":        true,
+	"repositories":    true,
+	"projects":        true,
+	"accounts":        true,
+	"certificates":    true,
+	"gpgkeys":         true,
+	"logs":            true,
+	"exec":            true,
+	"extensions":      true,
+}
+
+// LoadRbacPolicy reads the policy file into the options and validates the resulting policy
+func LoadRbacPolicy(options *install.RbacOptions) error {
+	if options.PolicyFile != "" {
+		content, err := ioutil.ReadFile(options.PolicyFile)
+		if err != nil {
+			return err
+		}
+		if options.Policy != "" && !strings.HasSuffix(options.Policy, "\n") {
+			options.Policy += "\n"
+		}
+		options.Policy += string(content)
+		options.PolicyFile = ""
+	}
+	return ValidatePolicy(options.Policy)
+}
+
+// ValidatePolicy checks the syntax of casbin policy csv the way argocd expects it:
+// "p, subject, resource, action, object, effect" and "g, subject, role"
+func ValidatePolicy(policyCsv string) error {
+	for n, line := range strings.Split(policyCsv, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		err := validatePolicyLine(fields)
+		if err != nil {
+			return errors.New(fmt.Sprintf("Invalid rbac policy line %d \"%s\": %s", n+1, line, err.Error()))
+		}
+	}
+	return nil
+}
+
+func validatePolicyLine(fields []string) error {
+	for _, field := range fields {
+		if field == "" {
+			return errors.New("empty field")
+		}
+	}
+	switch fields[0] {
+	case "p":
+		if len(fields) != 6 {
+			return errors.New("expected 6 fields: p, subject, resource, action, object, effect")
+		}
+		if !rbacResources[fields[2]] {
+			return errors.New(fmt.Sprintf("unknown resource \"%s\"", fields[2]))
+		}
+		if fields[5] != "allow" && fields[5] != "deny" {
+			return errors.New(fmt.Sprintf("effect must be allow or deny, got \"%s\"", fields[5]))
+		}
+	case "g":
+		if len(fields) != 3 {
+			return errors.New("expected 3 fields: g, subject, role")
+		}
+	default:
+		return errors.New(fmt.Sprintf("unknown policy type \"%s\", use p or g", fields[0]))
+	}
+	return nil
+}
+
+// ConfigureRbac merges the options into argocd-rbac-cm, existing policy lines are kept
+func ConfigureRbac(kubeClient kube.Kube, options install.RbacOptions) error {
+	rbac, err := kubeClient.GetConfigMapData(RbacConfigMapName)
+	if err != nil {
+		return err
+	}
+	rbac = copyData(rbac)
+
+	var lines []string
+	for _, line := range strings.Split(options.Policy, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	policyCsv, _ := appendLines(rbac["policy.csv"], lines)
+	if policyCsv != "" {
+		rbac["policy.csv"] = policyCsv
+	}
+	if options.DefaultRole != "" {
+		rbac["policy.default"] = options.DefaultRole
+	}
+	if len(options.Scopes) > 0 {
+		rbac["scopes"] = "[" + strings.Join(options.Scopes, ", ") + "]"
+	}
+	return kubeClient.SaveConfigMapData(RbacConfigMapName, rbac)
+}
+
+// IsRbacConfigured reports whether there is anything to merge into argocd-rbac-cm
+func IsRbacConfigured(options install.RbacOptions) bool {
+	return options.Policy != "" || options.PolicyFile != "" || options.DefaultRole != "" || len(options.Scopes) > 0
+}
@@ -0,0 +1,86 @@
+package argo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		wantErr string
+	}{
+		{
+			name:   "policy and group lines",
+			policy: "p, role:deployer, applications, sync, */*, allow\ng, my-org:devops, role:deployer",
+		},
+		{
+			name:   "deny effect",
+			policy: "p, role:readonly, clusters, delete, *, deny",
+		},
+		{
+			name:   "comments and blank lines are skipped",
+			policy: "# deployers\n\n  p, role:deployer, applications, get, */*, allow  \n\n# teams\ng, my-org:devops, role:deployer\n",
+		},
+		{
+			name:   "empty policy",
+			policy: "",
+		},
+		{
+			name:    "policy line with 5 fields",
+			policy:  "p, role:deployer, applications, sync, allow",
+			wantErr: "Invalid rbac policy line 1 \"p, role:deployer, applications, sync, allow\": expected 6 fields",
+		},
+		{
+			name:    "policy line with 7 fields",
+			policy:  "p, role:deployer, applications, sync, */*, allow, extra",
+			wantErr: "expected 6 fields",
+		},
+		{
+			name:    "group line with 4 fields",
+			policy:  "g, my-org:devops, role:deployer, extra",
+			wantErr: "expected 3 fields: g, subject, role",
+		},
+		{
+			name:    "bad effect",
+			policy:  "p, role:deployer, applications, sync, */*, permit",
+			wantErr: "effect must be allow or deny, got \"permit\"",
+		},
+		{
+			name:    "unknown resource",
+			policy:  "p, role:deployer, pipelines, sync, */*, allow",
+			wantErr: "unknown resource \"pipelines\"",
+		},
+		{
+			name:    "unknown policy type",
+			policy:  "r, role:deployer, applications, sync, */*, allow",
+			wantErr: "unknown policy type \"r\", use p or g",
+		},
+		{
+			name:    "empty field",
+			policy:  "p, role:deployer, , sync, */*, allow",
+			wantErr: "empty field",
+		},
+		{
+			name:    "line number counts comments and blank lines",
+			policy:  "# deployers\n\np, role:deployer, applications, sync, */*, allow\ng, my-org:devops",
+			wantErr: "Invalid rbac policy line 4",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePolicy(tt.policy)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
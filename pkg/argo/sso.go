@@ -0,0 +1,130 @@
+package argo
+
+import (
+	"errors"
+	"fmt"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/kube"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"strings"
+)
+
+// LoadSsoConfig reads dex config file into the options and validates them
+func LoadSsoConfig(options *install.SsoOptions) error {
+	if options.DexConfigFile != "" {
+		content, err := ioutil.ReadFile(options.DexConfigFile)
+		if err != nil {
+			return err
+		}
+		options.DexConfig = string(content)
+		options.DexConfigFile = ""
+	}
+
+	oidc := options.Oidc
+	if oidc.Issuer == "" && oidc.ClientID == "" {
+		if options.DexConfig == "" {
+			return nil
+		}
+		var dexConfig map[string]interface{}
+		err := yaml.Unmarshal([]byte(options.DexConfig), &dexConfig)
+		if err != nil {
+			return errors.New(fmt.Sprintf("Invalid dex config: \"%s\"", err.Error()))
+		}
+		if _, ok := dexConfig["connectors"]; !ok {
+			return errors.New(fmt.Sprint("Dex config has no connectors"))
+		}
+		return nil
+	}
+
+	if options.DexConfig != "" {
+		return errors.New(fmt.Sprint("Use either OIDC or dex config, not both"))
+	}
+	if oidc.Name == "" || oidc.Issuer == "" || oidc.ClientID == "" {
+		return errors.New(fmt.Sprint("OIDC name, issuer and client id are required"))
+	}
+	if oidc.ClientSecret != "" && oidc.ClientSecretRef != "" {
+		return errors.New(fmt.Sprint("Use either OIDC client secret or client secret reference, not both"))
+	}
+	if oidc.ClientSecretRef != "" && len(strings.Split(oidc.ClientSecretRef, ":")) != 2 {
+		return errors.New(fmt.Sprintf("Invalid OIDC client secret reference \"%s\", use <secret>:<key>", oidc.ClientSecretRef))
+	}
+	return nil
+}
+
+// IsSsoConfigured reports whether there is OIDC or dex configuration
+func IsSsoConfigured(options install.SsoOptions) bool {
+	return options.Oidc.Issuer != "" || options.DexConfig != "" || options.DexConfigFile != ""
+}
+
+// OidcClientSecretKey is the argocd-secret key of OIDC client secret, the provider name can't be
+// part of it as secret keys are limited to [-._a-zA-Z0-9]
+const OidcClientSecretKey = "oidc.clientSecret"
+
+// ConfigureUrl writes the external argocd url into argocd-cm, sso callbacks and notification links are built from it
+func ConfigureUrl(kubeClient kube.Kube, url string) error {
+	settings, err := kubeClient.GetConfigMapData(ConfigMapName)
+	if err != nil {
+		return err
+	}
+	if settings["url"] == url {
+		return nil
+	}
+	settings = copyData(settings)
+	settings["url"] = url
+	return kubeClient.SaveConfigMapData(ConfigMapName, settings)
+}
+
+// ConfigureSso writes OIDC or dex config into argocd-cm, OIDC client secret goes to argocd-secret
+func ConfigureSso(kubeClient kube.Kube, options install.SsoOptions) error {
+	settings, err := kubeClient.GetConfigMapData(ConfigMapName)
+	if err != nil {
+		return err
+	}
+	settings = copyData(settings)
+
+	if options.DexConfig != "" {
+		settings["dex.config"] = options.DexConfig
+		return kubeClient.SaveConfigMapData(ConfigMapName, settings)
+	}
+
+	oidc := options.Oidc
+	oidcConfig := yaml.MapSlice{
+		{Key: "name", Value: oidc.Name},
+		{Key: "issuer", Value: oidc.Issuer},
+		{Key: "clientID", Value: oidc.ClientID},
+	}
+	if oidc.ClientSecretRef != "" {
+		oidcConfig = append(oidcConfig, yaml.MapItem{Key: "clientSecret", Value: "$" + oidc.ClientSecretRef})
+	} else if oidc.ClientSecret != "" {
+		err = setArgoSecretKey(kubeClient, OidcClientSecretKey, oidc.ClientSecret)
+		if err != nil {
+			return err
+		}
+		oidcConfig = append(oidcConfig, yaml.MapItem{Key: "clientSecret", Value: "$" + OidcClientSecretKey})
+	}
+	if len(oidc.RequestedScopes) > 0 {
+		oidcConfig = append(oidcConfig, yaml.MapItem{Key: "requestedScopes", Value: oidc.RequestedScopes})
+	}
+	if oidc.GroupsClaim != "" {
+		oidcConfig = append(oidcConfig, yaml.MapItem{Key: "requestedIDTokenClaims", Value: map[string]interface{}{
+			oidc.GroupsClaim: map[string]interface{}{"essential": true},
+		}})
+	}
+	oidcYaml, err := yaml.Marshal(oidcConfig)
+	if err != nil {
+		return err
+	}
+	settings["oidc.config"] = string(oidcYaml)
+	return kubeClient.SaveConfigMapData(ConfigMapName, settings)
+}
+
+func setArgoSecretKey(kubeClient kube.Kube, key string, value string) error {
+	data, err := kubeClient.GetSecretData(kube.ArgoSecretName)
+	if err != nil {
+		return err
+	}
+	data = copyData(data)
+	data[key] = value
+	return kubeClient.SaveSecretData(kube.ArgoSecretName, data)
+}
@@ -0,0 +1,133 @@
+package checkpoint
+
+import (
+	"fmt"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/logger"
+	core "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"time"
+)
+
+// ConfigMapName is the config map in the installation namespace which keeps completed steps
+const ConfigMapName = "cf-gitops-install-checkpoints"
+
+const (
+	StepNamespace  = "namespace"
+	StepManifests  = "manifests"
+	StepExpose     = "expose"
+	StepHost       = "host"
+	StepPassword   = "password"
+	StepClusters   = "clusters"
+	StepRepo       = "repo"
+	StepRepoCreds  = "repo-creds"
+	StepUrl        = "url"
+	StepRbac       = "rbac"
+	StepSso        = "sso"
+	StepProjects   = "projects"
+	StepDefaultApp = "default-app"
+	StepBootstrap  = "bootstrap"
+	StepAppSet     = "application-set"
+	StepAgent      = "agent"
+)
+
+// Checkpoints are written with the client set directly, they are bookkeeping of
+// the installer and must not be part of the installation rollback
+type Checkpoints struct {
+	clientSet *kubernetes.Clientset
+	namespace string
+	completed map[string]string
+}
+
+// Load reads the checkpoints of previous failed installation from the cluster
+func Load(clientSet *kubernetes.Clientset, namespace string) (*Checkpoints, error) {
+	checkpoints := &Checkpoints{clientSet: clientSet, namespace: namespace, completed: map[string]string{}}
+	configMap, err := clientSet.CoreV1().ConfigMaps(namespace).Get(ConfigMapName, metav1.GetOptions{})
+	if k8sErrors.IsNotFound(err) {
+		return checkpoints, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for step, value := range configMap.Data {
+		checkpoints.completed[step] = value
+	}
+	return checkpoints, nil
+}
+
+// Disabled returns checkpoints which are never persisted
+func Disabled() *Checkpoints {
+	return &Checkpoints{completed: map[string]string{}}
+}
+
+func (c *Checkpoints) IsCompleted(step string) bool {
+	_, ok := c.completed[step]
+	return ok
+}
+
+// Value returns the value saved with the completed step
+func (c *Checkpoints) Value(step string) string {
+	return c.completed[step]
+}
+
+// Complete marks the step as completed, value is kept to be reused by the resumed installation.
+// Failure to persist the checkpoint doesn't break the installation
+func (c *Checkpoints) Complete(step string, value string) {
+	if value == "" {
+		value = time.Now().UTC().Format(time.RFC3339)
+	}
+	c.completed[step] = value
+	c.save()
+}
+
+// Snapshot returns a copy of the completed steps
+func (c *Checkpoints) Snapshot() map[string]string {
+	snapshot := make(map[string]string, len(c.completed))
+	for step, value := range c.completed {
+		snapshot[step] = value
+	}
+	return snapshot
+}
+
+// Restore replaces the completed steps with the snapshot, empty snapshot deletes the checkpoints
+func (c *Checkpoints) Restore(snapshot map[string]string) {
+	c.completed = map[string]string{}
+	for step, value := range snapshot {
+		c.completed[step] = value
+	}
+	c.save()
+}
+
+// Reset forgets all completed steps and deletes the checkpoints config map
+func (c *Checkpoints) Reset() {
+	c.completed = map[string]string{}
+	c.save()
+}
+
+func (c *Checkpoints) save() {
+	if c.clientSet == nil {
+		return
+	}
+	configMaps := c.clientSet.CoreV1().ConfigMaps(c.namespace)
+	if len(c.completed) == 0 {
+		err := configMaps.Delete(ConfigMapName, &metav1.DeleteOptions{})
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			logger.Warning(fmt.Sprintf("Can't delete installation checkpoints: \"%s\"", err.Error()))
+		}
+		return
+	}
+	configMap, err := configMaps.Get(ConfigMapName, metav1.GetOptions{})
+	if k8sErrors.IsNotFound(err) {
+		_, err = configMaps.Create(&core.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName, Namespace: c.namespace},
+			Data:       c.completed,
+		})
+	} else if err == nil {
+		configMap.Data = c.completed
+		_, err = configMaps.Update(configMap)
+	}
+	if err != nil {
+		logger.Warning(fmt.Sprintf("Can't save installation checkpoints: \"%s\"", err.Error()))
+	}
+}
@@ -3,8 +3,10 @@ package clusters
 import (
 	"encoding/base64"
 	"fmt"
-	"github.com/codefresh-io/argocd-listener/installer/pkg/logger"
-	argo "github.com/codefresh-io/argocd-sdk/pkg/api"
+	argoSdk "github.com/codefresh-io/argocd-sdk/pkg/api"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/argo"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/kube"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/logger"
 	"github.com/codefresh-io/go-sdk/pkg/codefresh"
 )
 
@@ -28,7 +30,9 @@ func GetAvailableClusters(cfClustersApi codefresh.IClusterAPI) ([]*codefresh.Clu
 	return filterClusters(clustersList), nil
 }
 
-func ImportFromCodefresh(clusters []string, cfClustersApi codefresh.IClusterAPI, argoClustersApi argo.ClusterApi) error {
+// ImportFromCodefresh registers the clusters in argocd, with labelSecrets their secrets are labeled
+// with argo.ImportedClusterLabels for the cluster ApplicationSet
+func ImportFromCodefresh(clusters []string, cfClustersApi codefresh.IClusterAPI, argoApi argo.Api, kubeClient kube.Kube, labelSecrets bool) error {
 	if len(clusters) < 1 {
 		logger.Warning(fmt.Sprint("Import clusters skipped because nothing was selected..."))
 		return nil
@@ -45,12 +49,12 @@ func ImportFromCodefresh(clusters []string, cfClustersApi codefresh.IClusterAPI,
 			return err
 		}
 
-		_, err = argoClustersApi.CreateCluster(argo.ClusterOpt{
+		err = argoApi.CreateCluster(argoSdk.ClusterOpt{
 			Name:   CODEFRESH_CLUSTER_PREFIX + clusterSelector,
 			Server: cluster.Url,
-			Config: argo.ClusterConfig{
+			Config: argoSdk.ClusterConfig{
 				BearerToken: string(bearer),
-				TlsClientConfig: argo.TlsClientConfig{
+				TlsClientConfig: argoSdk.TlsClientConfig{
 					CaData:   cluster.Ca,
 					Insecure: false,
 				},
@@ -59,6 +63,13 @@ func ImportFromCodefresh(clusters []string, cfClustersApi codefresh.IClusterAPI,
 		if err != nil {
 			return err
 		}
+		if labelSecrets {
+			err = kubeClient.LabelClusterSecret(cluster.Url, argo.ImportedClusterLabels)
+			if err != nil {
+				// cluster is registered, it is just not targeted by the application set
+				logger.Warning(fmt.Sprintf("Can't label cluster \"%s\" for application set: \"%s\"", clusterSelector, err.Error()))
+			}
+		}
 		logger.Success(fmt.Sprintf("Successfull created cluster \"%s\"", clusterSelector))
 	}
 
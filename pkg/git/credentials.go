@@ -0,0 +1,98 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"github.com/codefresh-io/go-sdk/pkg/codefresh"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// RepoCredentials are argocd repository credentials of the git context,
+// ssh private key is used for git@ urls and username with password or github app for https urls
+type RepoCredentials struct {
+	Username      string
+	Password      string
+	SshPrivateKey string
+	GithubApp     *GithubAppCredentials
+}
+
+type GithubAppCredentials struct {
+	AppID             int64
+	InstallationID    int64
+	PrivateKey        string
+	EnterpriseBaseUrl string
+}
+
+// Credentials maps the git context auth to argocd repository credentials, every provider
+// expects its own username next to the token or app password
+func Credentials(context codefresh.ContextPayload) (RepoCredentials, error) {
+	auth := context.Spec.Data.Auth
+	if context.Spec.Type == "git.github-app" {
+		return githubAppCredentials(context)
+	}
+	if auth.Type != "basic" && auth.Type != "ssh" {
+		return RepoCredentials{}, errors.New(fmt.Sprintf("Auth type \"%s\" of git context \"%s\" is not supported", auth.Type, context.Metadata.Name))
+	}
+	if auth.Password == "" {
+		if auth.SshPrivateKey == "" {
+			return RepoCredentials{}, errors.New(fmt.Sprintf("Git context \"%s\" has neither token nor ssh private key", context.Metadata.Name))
+		}
+		return RepoCredentials{SshPrivateKey: auth.SshPrivateKey}, nil
+	}
+
+	credentials := RepoCredentials{Username: auth.Username, Password: auth.Password, SshPrivateKey: auth.SshPrivateKey}
+	switch context.Spec.Type {
+	case "git.gitlab":
+		// gitlab personal and project access tokens
+		credentials.Username = "oauth2"
+	case "git.bitbucket", "git.bitbucket-server", "git.stash":
+		// app password and http access token are bound to the user
+		if credentials.Username == "" {
+			return RepoCredentials{}, errors.New(fmt.Sprintf("Bitbucket git context \"%s\" has no username", context.Metadata.Name))
+		}
+	default:
+		// github and azure devops tokens accept any username
+		if credentials.Username == "" {
+			credentials.Username = "git"
+		}
+	}
+	return credentials, nil
+}
+
+func githubAppCredentials(context codefresh.ContextPayload) (RepoCredentials, error) {
+	auth := context.Spec.Data.Auth
+	appID, err := strconv.ParseInt(auth.AppId, 10, 64)
+	if err != nil {
+		return RepoCredentials{}, errors.New(fmt.Sprintf("Invalid app id of git context \"%s\"", context.Metadata.Name))
+	}
+	installationID, err := strconv.ParseInt(auth.InstallationId, 10, 64)
+	if err != nil {
+		return RepoCredentials{}, errors.New(fmt.Sprintf("Invalid installation id of git context \"%s\"", context.Metadata.Name))
+	}
+	if auth.PrivateKey == "" {
+		return RepoCredentials{}, errors.New(fmt.Sprintf("Git context \"%s\" has no private key", context.Metadata.Name))
+	}
+	return RepoCredentials{GithubApp: &GithubAppCredentials{
+		AppID:             appID,
+		InstallationID:    installationID,
+		PrivateKey:        auth.PrivateKey,
+		EnterpriseBaseUrl: enterpriseBaseUrl(auth.ApiURL, auth.ApiHost),
+	}}, nil
+}
+
+// enterpriseBaseUrl returns github enterprise api url, it's empty for github.com
+func enterpriseBaseUrl(apiUrl string, apiHost string) string {
+	if apiUrl == "" && apiHost != "" {
+		apiUrl = "https://" + apiHost
+	}
+	parsed, err := url.Parse(apiUrl)
+	if apiUrl == "" || err != nil || parsed.Hostname() == "api.github.com" || parsed.Hostname() == "github.com" {
+		return ""
+	}
+	if !strings.HasSuffix(strings.TrimSuffix(parsed.Path, "/"), "/api/v3") {
+		parsed.Path = strings.TrimSuffix(parsed.Path, "/") + "/api/v3"
+	}
+	return parsed.String()
+}
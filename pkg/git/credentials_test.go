@@ -0,0 +1,135 @@
+package git
+
+import (
+	"github.com/codefresh-io/go-sdk/pkg/codefresh"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func gitContext(contextType string, authType string, username string, password string, sshKey string) codefresh.ContextPayload {
+	var context codefresh.ContextPayload
+	context.Metadata.Name = "git"
+	context.Spec.Type = contextType
+	context.Spec.Data.Auth.Type = authType
+	context.Spec.Data.Auth.Username = username
+	context.Spec.Data.Auth.Password = password
+	context.Spec.Data.Auth.SshPrivateKey = sshKey
+	return context
+}
+
+func githubAppContext(appID string, installationID string, privateKey string, apiUrl string) codefresh.ContextPayload {
+	var context codefresh.ContextPayload
+	context.Metadata.Name = "app"
+	context.Spec.Type = "git.github-app"
+	context.Spec.Data.Auth.AppId = appID
+	context.Spec.Data.Auth.InstallationId = installationID
+	context.Spec.Data.Auth.PrivateKey = privateKey
+	context.Spec.Data.Auth.ApiURL = apiUrl
+	return context
+}
+
+func TestCredentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		context codefresh.ContextPayload
+		want    RepoCredentials
+		wantErr string
+	}{
+		{
+			name:    "github token accepts any username",
+			context: gitContext("git.github", "basic", "", "token", ""),
+			want:    RepoCredentials{Username: "git", Password: "token"},
+		},
+		{
+			name:    "gitlab token is used with oauth2",
+			context: gitContext("git.gitlab", "basic", "john", "token", ""),
+			want:    RepoCredentials{Username: "oauth2", Password: "token"},
+		},
+		{
+			name:    "bitbucket app password keeps its username",
+			context: gitContext("git.bitbucket", "basic", "john", "app-password", ""),
+			want:    RepoCredentials{Username: "john", Password: "app-password"},
+		},
+		{
+			name:    "bitbucket app password requires username",
+			context: gitContext("git.bitbucket", "basic", "", "app-password", ""),
+			wantErr: "Bitbucket git context \"git\" has no username",
+		},
+		{
+			name:    "bitbucket server token requires username",
+			context: gitContext("git.bitbucket-server", "basic", "", "token", ""),
+			wantErr: "has no username",
+		},
+		{
+			name:    "azure token keeps its username",
+			context: gitContext("git.azure", "basic", "john", "token", ""),
+			want:    RepoCredentials{Username: "john", Password: "token"},
+		},
+		{
+			name:    "azure token accepts any username",
+			context: gitContext("git.azure", "basic", "", "token", ""),
+			want:    RepoCredentials{Username: "git", Password: "token"},
+		},
+		{
+			name:    "ssh only context",
+			context: gitContext("git.generic", "ssh", "", "", "ssh-key"),
+			want:    RepoCredentials{SshPrivateKey: "ssh-key"},
+		},
+		{
+			name:    "context without token and ssh key",
+			context: gitContext("git.github", "basic", "john", "", ""),
+			wantErr: "has neither token nor ssh private key",
+		},
+		{
+			name:    "unsupported auth type",
+			context: gitContext("git.github", "oauth2", "", "token", ""),
+			wantErr: "Auth type \"oauth2\" of git context \"git\" is not supported",
+		},
+		{
+			name:    "github app",
+			context: githubAppContext("12", "34", "app-key", ""),
+			want:    RepoCredentials{GithubApp: &GithubAppCredentials{AppID: 12, InstallationID: 34, PrivateKey: "app-key"}},
+		},
+		{
+			name:    "github enterprise app",
+			context: githubAppContext("12", "34", "app-key", "https://github.example.com"),
+			want: RepoCredentials{GithubApp: &GithubAppCredentials{
+				AppID: 12, InstallationID: 34, PrivateKey: "app-key", EnterpriseBaseUrl: "https://github.example.com/api/v3",
+			}},
+		},
+		{
+			name:    "github app with invalid app id",
+			context: githubAppContext("app", "34", "app-key", ""),
+			wantErr: "Invalid app id of git context \"app\"",
+		},
+		{
+			name:    "github app with invalid installation id",
+			context: githubAppContext("12", "", "app-key", ""),
+			wantErr: "Invalid installation id of git context \"app\"",
+		},
+		{
+			name:    "github app without private key",
+			context: githubAppContext("12", "34", "", ""),
+			wantErr: "Git context \"app\" has no private key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Credentials(tt.context)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Credentials() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,19 +1,76 @@
 package git
 
-import "github.com/codefresh-io/go-sdk/pkg/codefresh"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/codefresh-io/go-sdk/pkg/codefresh"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
 
-func GetAvailableContexts(cfContextsApi codefresh.IContextAPI) (*[]codefresh.ContextPayload, error) {
+// providers are the codefresh git context types and their display names,
+// sdk GetGitContexts only queries github and gitlab so contexts are requested directly
+var providers = map[string]string{
+	"git.github":           "GitHub",
+	"git.github-app":       "GitHub App",
+	"git.gitlab":           "GitLab",
+	"git.bitbucket":        "Bitbucket",
+	"git.bitbucket-server": "Bitbucket Server",
+	"git.stash":            "Bitbucket Server",
+	"git.azure":            "Azure DevOps",
+	"git.gerrit":           "Gerrit",
+	"git.generic":          "Git",
+}
+
+// Provider returns display name of the git provider of the context
+func Provider(context codefresh.ContextPayload) string {
+	if provider, ok := providers[context.Spec.Type]; ok {
+		return provider
+	}
+	return strings.TrimPrefix(context.Spec.Type, "git.")
+}
+
+func GetAvailableContexts(codefreshHost string, codefreshToken string) (*[]codefresh.ContextPayload, error) {
 	var result = []codefresh.ContextPayload{}
 
-	err, allContexts := cfContextsApi.GetGitContexts()
+	allContexts, err := getGitContexts(codefreshHost, codefreshToken)
 	if err != nil {
 		return &result, err
 	}
-	for _, context := range *allContexts {
-		//context.Spec.Data.Auth.SshPrivateKey != "" &&
-		if context.Spec.Data.Auth.Type == "basic" && context.Spec.Type == "git.github" {
+	for _, context := range allContexts {
+		if _, err := Credentials(context); err == nil {
 			result = append(result, context)
 		}
 	}
 	return &result, nil
 }
+
+func getGitContexts(codefreshHost string, codefreshToken string) ([]codefresh.ContextPayload, error) {
+	var result []codefresh.ContextPayload
+
+	query := url.Values{}
+	for contextType := range providers {
+		query.Add("type", contextType)
+	}
+	query.Set("decrypt", "true")
+
+	request, err := http.NewRequest("GET", strings.TrimSuffix(codefreshHost, "/")+"/api/contexts?"+query.Encode(), nil)
+	if err != nil {
+		return result, err
+	}
+	request.Header.Set("Authorization", codefreshToken)
+	client := &http.Client{Timeout: 30 * time.Second}
+	response, err := client.Do(request)
+	if err != nil {
+		return result, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return result, errors.New(fmt.Sprintf("Can't get git contexts from codefresh: %s", response.Status))
+	}
+	err = json.NewDecoder(response.Body).Decode(&result)
+	return result, err
+}
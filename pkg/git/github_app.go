@@ -0,0 +1,77 @@
+package git
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const githubApiUrl = "https://api.github.com"
+
+// githubAppToken exchanges JWT signed with the app private key for installation access token
+func githubAppToken(app *GithubAppCredentials) (string, error) {
+	jwt, err := githubAppJwt(app)
+	if err != nil {
+		return "", err
+	}
+	apiUrl := githubApiUrl
+	if app.EnterpriseBaseUrl != "" {
+		apiUrl = strings.TrimSuffix(app.EnterpriseBaseUrl, "/")
+	}
+	request, err := http.NewRequest("POST", fmt.Sprintf("%s/app/installations/%d/access_tokens", apiUrl, app.InstallationID), nil)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Authorization", "Bearer "+jwt)
+	request.Header.Set("Accept", "application/vnd.github.v3+json")
+	client := &http.Client{Timeout: 30 * time.Second}
+	response, err := client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusCreated {
+		return "", errors.New(fmt.Sprintf("Can't get github app installation token: %s", response.Status))
+	}
+	var result struct {
+		Token string `json:"token"`
+	}
+	err = json.NewDecoder(response.Body).Decode(&result)
+	return result.Token, err
+}
+
+func githubAppJwt(app *GithubAppCredentials) (string, error) {
+	block, _ := pem.Decode([]byte(app.PrivateKey))
+	if block == nil {
+		return "", errors.New(fmt.Sprint("Invalid github app private key"))
+	}
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("Invalid github app private key: \"%s\"", err.Error()))
+	}
+
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": app.AppID,
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
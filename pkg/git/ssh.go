@@ -0,0 +1,171 @@
+package git
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// IsSshUrl reports whether the repo url is git@host:path or ssh:// url
+func IsSshUrl(repoUrl string) bool {
+	return strings.HasPrefix(repoUrl, "ssh://") || (strings.Contains(repoUrl, "@") && !strings.Contains(repoUrl, "://"))
+}
+
+// ToSshUrl converts https repo url to git@host:path.git
+func ToSshUrl(repoUrl string) (string, error) {
+	if IsSshUrl(repoUrl) {
+		return repoUrl, nil
+	}
+	parsed, err := url.Parse(repoUrl)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Host == "" {
+		return "", errors.New(fmt.Sprintf("Invalid repo url \"%s\"", repoUrl))
+	}
+	path := strings.TrimPrefix(parsed.Path, "/")
+	if !strings.HasSuffix(path, ".git") {
+		path += ".git"
+	}
+	return fmt.Sprintf("git@%s:%s", parsed.Hostname(), path), nil
+}
+
+// SshHost returns host:port of ssh repo url
+func SshHost(repoUrl string) (string, error) {
+	if strings.HasPrefix(repoUrl, "ssh://") {
+		parsed, err := url.Parse(repoUrl)
+		if err != nil {
+			return "", err
+		}
+		if parsed.Port() == "" {
+			return net.JoinHostPort(parsed.Hostname(), "22"), nil
+		}
+		return parsed.Host, nil
+	}
+	hostPath := repoUrl[strings.Index(repoUrl, "@")+1:]
+	separator := strings.Index(hostPath, ":")
+	if separator < 1 {
+		return "", errors.New(fmt.Sprintf("Invalid ssh repo url \"%s\"", repoUrl))
+	}
+	return net.JoinHostPort(hostPath[:separator], "22"), nil
+}
+
+// ReadKnownHosts reads ssh_known_hosts file and validates its entries
+func ReadKnownHosts(path string) ([]string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		_, _, _, _, _, err = ssh.ParseKnownHosts([]byte(line))
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Invalid known hosts entry \"%s\": \"%s\"", line, err.Error()))
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// KnownHostsEntries returns entries of the known hosts file and of the hosts with pinned
+// fingerprints given as host=SHA256:...
+func KnownHostsEntries(knownHostsFile string, hostFingerprints []string) ([]string, error) {
+	var entries []string
+	if knownHostsFile != "" {
+		lines, err := ReadKnownHosts(knownHostsFile)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, lines...)
+	}
+	for _, pinned := range hostFingerprints {
+		hostFingerprint := strings.SplitN(pinned, "=", 2)
+		if len(hostFingerprint) != 2 {
+			return nil, errors.New(fmt.Sprintf("Invalid host fingerprint \"%s\", use host=SHA256:...", pinned))
+		}
+		line, err := ScanHostKey(hostFingerprint[0], hostFingerprint[1])
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, line)
+	}
+	return entries, nil
+}
+
+// knownHostsCallback verifies host keys against the known hosts entries
+func knownHostsCallback(knownHosts []string) (ssh.HostKeyCallback, error) {
+	file, err := ioutil.TempFile("", "known_hosts")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(strings.Join(knownHosts, "\n") + "\n")
+	closeErr := file.Close()
+	if err != nil {
+		return nil, err
+	}
+	if closeErr != nil {
+		return nil, closeErr
+	}
+	return knownhosts.New(file.Name())
+}
+
+// IsKnownHost reports whether the known hosts entries have a key of the host:port, hashed entries included
+func IsKnownHost(knownHosts []string, host string) (bool, error) {
+	callback, err := knownHostsCallback(knownHosts)
+	if err != nil {
+		return false, err
+	}
+	// any key of the host is enough, so it's checked with a key nobody has
+	probe, err := ssh.NewPublicKey(ed25519.PublicKey(make([]byte, ed25519.PublicKeySize)))
+	if err != nil {
+		return false, err
+	}
+	err = callback(host, &net.TCPAddr{IP: net.IPv4zero}, probe)
+	if keyErr, ok := err.(*knownhosts.KeyError); ok {
+		return len(keyErr.Want) > 0, nil
+	}
+	return err == nil, err
+}
+
+// ScanHostKey returns known hosts entry of the host key, it's accepted only when
+// its SHA256 fingerprint matches the pinned one
+func ScanHostKey(host string, fingerprint string) (string, error) {
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, "22")
+	}
+	var hostKey ssh.PublicKey
+	config := &ssh.ClientConfig{
+		User: "git",
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			hostKey = key
+			// key is captured, there is no need to authenticate
+			return errors.New("host key scanned")
+		},
+		Timeout: 10 * time.Second,
+	}
+	_, err := ssh.Dial("tcp", host, config)
+	if hostKey == nil {
+		if err == nil {
+			err = errors.New("no host key")
+		}
+		return "", errors.New(fmt.Sprintf("Can't scan host key of \"%s\": \"%s\"", host, err.Error()))
+	}
+	actual := ssh.FingerprintSHA256(hostKey)
+	if actual != fingerprint {
+		return "", errors.New(fmt.Sprintf("Host key fingerprint of \"%s\" is \"%s\", expected \"%s\"", host, actual, fingerprint))
+	}
+	return knownhosts.Line([]string{knownhosts.Normalize(host)}, hostKey), nil
+}
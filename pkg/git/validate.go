@@ -0,0 +1,196 @@
+package git
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/ssh"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidateUrl checks syntax of https or ssh repo url
+func ValidateUrl(repoUrl string) error {
+	if IsSshUrl(repoUrl) {
+		_, err := SshHost(repoUrl)
+		return err
+	}
+	parsed, err := url.Parse(repoUrl)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Invalid repo url \"%s\": \"%s\"", repoUrl, err.Error()))
+	}
+	if (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" || strings.Trim(parsed.Path, "/") == "" {
+		return errors.New(fmt.Sprintf("Invalid repo url \"%s\", use https://host/path or git@host:path", repoUrl))
+	}
+	return nil
+}
+
+// ListBranches does what git ls-remote --heads does with the credentials and returns the branch names,
+// host key of ssh repo is verified against the known hosts entries
+func ListBranches(repoUrl string, credentials RepoCredentials, knownHosts []string) ([]string, error) {
+	err := ValidateUrl(repoUrl)
+	if err != nil {
+		return nil, err
+	}
+	var refs []string
+	if IsSshUrl(repoUrl) {
+		refs, err = lsRemoteSsh(repoUrl, credentials, knownHosts)
+	} else {
+		refs, err = lsRemoteHttp(repoUrl, credentials)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var branches []string
+	for _, ref := range refs {
+		if strings.HasPrefix(ref, "refs/heads/") {
+			branches = append(branches, strings.TrimPrefix(ref, "refs/heads/"))
+		}
+	}
+	return branches, nil
+}
+
+// lsRemoteHttp reads refs advertisement of git smart http protocol
+func lsRemoteHttp(repoUrl string, credentials RepoCredentials) ([]string, error) {
+	request, err := http.NewRequest("GET", strings.TrimSuffix(repoUrl, "/")+"/info/refs?service=git-upload-pack", nil)
+	if err != nil {
+		return nil, err
+	}
+	if credentials.GithubApp != nil {
+		token, err := githubAppToken(credentials.GithubApp)
+		if err != nil {
+			return nil, err
+		}
+		request.SetBasicAuth("x-access-token", token)
+	} else if credentials.Password != "" {
+		request.SetBasicAuth(credentials.Username, credentials.Password)
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	switch response.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, errors.New(fmt.Sprintf("Access to \"%s\" is denied, check credentials of git integration", repoUrl))
+	case http.StatusNotFound:
+		return nil, errors.New(fmt.Sprintf("Repo \"%s\" is not found", repoUrl))
+	default:
+		return nil, errors.New(fmt.Sprintf("Can't access \"%s\": %s", repoUrl, response.Status))
+	}
+	if response.Header.Get("Content-Type") != "application/x-git-upload-pack-advertisement" {
+		return nil, errors.New(fmt.Sprintf("\"%s\" is not a git repo", repoUrl))
+	}
+
+	reader := bufio.NewReader(response.Body)
+	// "# service=git-upload-pack" and flush precede the refs
+	_, err = readPktLines(reader)
+	if err != nil {
+		return nil, err
+	}
+	return readPktLines(reader)
+}
+
+// lsRemoteSsh reads refs advertisement of git-upload-pack
+func lsRemoteSsh(repoUrl string, credentials RepoCredentials, knownHosts []string) ([]string, error) {
+	if credentials.SshPrivateKey == "" {
+		return nil, errors.New(fmt.Sprintf("Ssh private key is required for \"%s\"", repoUrl))
+	}
+	signer, err := ssh.ParsePrivateKey([]byte(credentials.SshPrivateKey))
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Invalid ssh private key: \"%s\"", err.Error()))
+	}
+	host, err := SshHost(repoUrl)
+	if err != nil {
+		return nil, err
+	}
+	known, err := IsKnownHost(knownHosts, host)
+	if err != nil {
+		return nil, err
+	}
+	if !known {
+		return nil, errors.New(fmt.Sprintf("Host \"%s\" is not known, use --ssh-known-hosts-file or --ssh-host-fingerprint", host))
+	}
+	user, path := sshUserPath(repoUrl)
+	hostKeyCallback, err := knownHostsCallback(knownHosts)
+	if err != nil {
+		return nil, err
+	}
+	client, err := ssh.Dial("tcp", host, &ssh.ClientConfig{
+		User:            user,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         30 * time.Second,
+	})
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Can't connect to \"%s\": \"%s\"", host, err.Error()))
+	}
+	defer client.Close()
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	err = session.Start(fmt.Sprintf("git-upload-pack '%s'", path))
+	if err != nil {
+		return nil, err
+	}
+	refs, err := readPktLines(bufio.NewReader(stdout))
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Repo \"%s\" is not accessible: \"%s\"", repoUrl, err.Error()))
+	}
+	return refs, nil
+}
+
+func sshUserPath(repoUrl string) (string, string) {
+	if strings.HasPrefix(repoUrl, "ssh://") {
+		parsed, _ := url.Parse(repoUrl)
+		return parsed.User.Username(), parsed.Path
+	}
+	user := repoUrl[:strings.Index(repoUrl, "@")]
+	hostPath := repoUrl[strings.Index(repoUrl, "@")+1:]
+	return user, hostPath[strings.Index(hostPath, ":")+1:]
+}
+
+// readPktLines reads git pkt-lines until flush, returns refs of the lines
+func readPktLines(reader *bufio.Reader) ([]string, error) {
+	var refs []string
+	for {
+		lengthHex := make([]byte, 4)
+		_, err := io.ReadFull(reader, lengthHex)
+		if err != nil {
+			return nil, err
+		}
+		length, err := strconv.ParseUint(string(lengthHex), 16, 16)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Invalid git response \"%s\"", string(lengthHex)))
+		}
+		if length == 0 {
+			return refs, nil
+		}
+		if length < 4 {
+			return nil, errors.New(fmt.Sprintf("Invalid git pkt-line length %d", length))
+		}
+		line := make([]byte, length-4)
+		_, err = io.ReadFull(reader, line)
+		if err != nil {
+			return nil, err
+		}
+		// "<sha> <ref>\x00<capabilities>\n", capabilities come with the first ref only
+		content := strings.TrimSuffix(strings.SplitN(string(line), "\x00", 2)[0], "\n")
+		fields := strings.SplitN(content, " ", 2)
+		if len(fields) == 2 && !strings.HasPrefix(content, "#") {
+			refs = append(refs, fields[1])
+		}
+	}
+}
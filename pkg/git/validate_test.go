@@ -0,0 +1,245 @@
+package git
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const uploadPackAdvertisement = "application/x-git-upload-pack-advertisement"
+
+func pktLine(line string) string {
+	return fmt.Sprintf("%04x%s", len(line)+4, line)
+}
+
+func advertisement(refs ...string) string {
+	body := pktLine("# service=git-upload-pack\n") + "0000"
+	for i, ref := range refs {
+		line := "95d09f2b10159347eece71399a7e2e907ea3df4f " + ref
+		if i == 0 {
+			line += "\x00multi_ack thin-pack side-band side-band-64k ofs-delta shallow no-progress include-tag"
+		}
+		body += pktLine(line + "\n")
+	}
+	return body + "0000"
+}
+
+// newGitServer serves refs advertisement of git smart http protocol for /org/repo.git
+func newGitServer(t *testing.T, username string, password string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("service") != "git-upload-pack" {
+			t.Errorf("unexpected service %q", r.URL.Query().Get("service"))
+		}
+		switch r.URL.Path {
+		case "/org/repo.git/info/refs":
+			user, pass, ok := r.BasicAuth()
+			if username != "" && (!ok || user != username || pass != password) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", uploadPackAdvertisement)
+			_, _ = w.Write([]byte(advertisement("HEAD", "refs/heads/main", "refs/heads/feature/login", "refs/pull/1/head", "refs/tags/v1.0.0")))
+		case "/org/forbidden.git/info/refs":
+			w.WriteHeader(http.StatusForbidden)
+		case "/org/page/info/refs":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte("<html>sign in</html>"))
+		case "/org/empty.git/info/refs":
+			w.Header().Set("Content-Type", uploadPackAdvertisement)
+			_, _ = w.Write([]byte(pktLine("# service=git-upload-pack\n") + "0000" + "0000"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestListBranches(t *testing.T) {
+	server := newGitServer(t, "x-token-auth", "secret")
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		repo        string
+		credentials RepoCredentials
+		want        []string
+		wantErr     string
+	}{
+		{
+			name:        "branches of accessible repo",
+			repo:        "/org/repo.git",
+			credentials: RepoCredentials{Username: "x-token-auth", Password: "secret"},
+			want:        []string{"main", "feature/login"},
+		},
+		{
+			name:        "trailing slash",
+			repo:        "/org/repo.git/",
+			credentials: RepoCredentials{Username: "x-token-auth", Password: "secret"},
+			want:        []string{"main", "feature/login"},
+		},
+		{
+			name:        "repo without branches",
+			repo:        "/org/empty.git",
+			credentials: RepoCredentials{Username: "x-token-auth", Password: "secret"},
+		},
+		{
+			name:        "wrong password",
+			repo:        "/org/repo.git",
+			credentials: RepoCredentials{Username: "x-token-auth", Password: "wrong"},
+			wantErr:     "is denied",
+		},
+		{
+			name:    "no credentials",
+			repo:    "/org/repo.git",
+			wantErr: "is denied",
+		},
+		{
+			name:        "forbidden",
+			repo:        "/org/forbidden.git",
+			credentials: RepoCredentials{Username: "x-token-auth", Password: "secret"},
+			wantErr:     "is denied",
+		},
+		{
+			name:        "not found",
+			repo:        "/org/missing.git",
+			credentials: RepoCredentials{Username: "x-token-auth", Password: "secret"},
+			wantErr:     "is not found",
+		},
+		{
+			name:        "not a git repo",
+			repo:        "/org/page",
+			credentials: RepoCredentials{Username: "x-token-auth", Password: "secret"},
+			wantErr:     "is not a git repo",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ListBranches(server.URL+tt.repo, tt.credentials, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("branches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadPktLinesInvalid(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", uploadPackAdvertisement)
+		_, _ = w.Write([]byte(pktLine("# service=git-upload-pack\n") + "0000" + "zzzz"))
+	}))
+	defer server.Close()
+
+	_, err := ListBranches(server.URL+"/org/repo.git", RepoCredentials{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "Invalid git response") {
+		t.Fatalf("error = %v, want invalid git response", err)
+	}
+}
+
+func TestValidateUrl(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"https://github.com/org/repo", false},
+		{"https://github.com/org/repo.git", false},
+		{"http://gitea.local:3000/org/repo.git", false},
+		{"git@github.com:org/repo.git", false},
+		{"ssh://git@bitbucket.example.com:7999/org/repo.git", false},
+		{"https://github.com", true},
+		{"https://github.com/", true},
+		{"ftp://github.com/org/repo", true},
+		{"github.com/org/repo", true},
+		{"git@github.com", true},
+		{"https://%zz/org/repo", true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateUrl(tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateUrl(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+		}
+	}
+}
+
+func TestKnownHostsCallback(t *testing.T) {
+	newKey := func() ssh.PublicKey {
+		public, _, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := ssh.NewPublicKey(public)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+	knownKey := newKey()
+	callback, err := knownHostsCallback([]string{knownhosts.Line([]string{"github.com"}, knownKey)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote := &net.TCPAddr{IP: net.ParseIP("140.82.121.4"), Port: 22}
+
+	if err := callback("github.com:22", remote, knownKey); err != nil {
+		t.Errorf("known host key is rejected: %v", err)
+	}
+	if err := callback("github.com:22", remote, newKey()); err == nil {
+		t.Error("changed host key is accepted")
+	}
+	if err := callback("gitlab.com:22", remote, knownKey); err == nil {
+		t.Error("unknown host is accepted")
+	}
+}
+
+func TestIsKnownHost(t *testing.T) {
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		knownHosts []string
+		host       string
+		want       bool
+	}{
+		{"plain entry", []string{knownhosts.Line([]string{"github.com"}, key)}, "github.com:22", true},
+		{"hashed entry", []string{knownhosts.Line([]string{knownhosts.HashHostname("github.com")}, key)}, "github.com:22", true},
+		{"entry with port", []string{knownhosts.Line([]string{"[bitbucket.example.com]:7999"}, key)}, "bitbucket.example.com:7999", true},
+		{"other port", []string{knownhosts.Line([]string{"bitbucket.example.com"}, key)}, "bitbucket.example.com:7999", false},
+		{"unknown host", []string{knownhosts.Line([]string{"github.com"}, key)}, "gitlab.com:22", false},
+		{"no entries", nil, "github.com:22", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := IsKnownHost(tt.knownHosts, tt.host)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("IsKnownHost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
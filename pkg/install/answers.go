@@ -0,0 +1,16 @@
+package install
+
+import (
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+)
+
+// LoadAnswers reads the answers file and applies it on top of the given options,
+// only the keys present in the file are overridden
+func LoadAnswers(answersPath string, options *CmdOptions) error {
+	data, err := ioutil.ReadFile(answersPath)
+	if err != nil {
+		return err
+	}
+	return yaml.UnmarshalStrict(data, options)
+}
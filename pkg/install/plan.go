@@ -0,0 +1,66 @@
+package install
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+type (
+	// PlannedAction is a change that the installation would apply
+	PlannedAction struct {
+		Action    string `json:"action"`
+		Kind      string `json:"kind"`
+		Name      string `json:"name"`
+		Namespace string `json:"namespace,omitempty"`
+		Details   string `json:"details,omitempty"`
+	}
+
+	// Plan collects the changes of the dry run installation
+	Plan struct {
+		ManifestPath string          `json:"manifestPath"`
+		Namespace    string          `json:"namespace"`
+		ArgoHost     string          `json:"argoHost"`
+		Actions      []PlannedAction `json:"actions"`
+	}
+)
+
+func (p *Plan) Record(action PlannedAction) {
+	p.Actions = append(p.Actions, action)
+}
+
+// Render writes the plan in "text" or "json" format
+func (p *Plan) Render(out io.Writer, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(p)
+	case "text", "":
+		_, _ = fmt.Fprintln(out, "Installation plan (dry run):")
+		_, _ = fmt.Fprintf(out, "  manifest:  %s\n", p.ManifestPath)
+		_, _ = fmt.Fprintf(out, "  namespace: %s\n", p.Namespace)
+		_, _ = fmt.Fprintf(out, "  argo host: %s\n", p.ArgoHost)
+		_, _ = fmt.Fprintln(out, "Actions:")
+		for _, action := range p.Actions {
+			_, _ = fmt.Fprintf(out, "  %s\n", action.String())
+		}
+		return nil
+	default:
+		return errors.New(fmt.Sprintf("Unknown plan format \"%s\"", format))
+	}
+}
+
+func (a PlannedAction) String() string {
+	result := fmt.Sprintf("%s %s", a.Action, a.Kind)
+	if a.Namespace != "" {
+		result += fmt.Sprintf(" %s/%s", a.Namespace, a.Name)
+	} else {
+		result += " " + a.Name
+	}
+	if a.Details != "" {
+		result += fmt.Sprintf(" (%s)", a.Details)
+	}
+	return result
+}
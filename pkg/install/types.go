@@ -1,44 +1,235 @@
 package install
 
+import "time"
+
+const (
+	ExposeLoadBalancer = "loadbalancer"
+	ExposeIngress      = "ingress"
+	ExposeNodePort     = "nodeport"
+	ExposeNone         = "none"
+	// ExposePortForward runs the installation through local port-forward to argocd-server
+	ExposePortForward = "port-forward"
+)
+
+// PasswordPolicy is the rules admin password must satisfy
+type PasswordPolicy struct {
+	MinLength int `yaml:"minLength"`
+	// RequireComplexity requires lower and upper case letters and digits
+	RequireComplexity bool `yaml:"requireComplexity"`
+}
+
+const (
+	SyncPolicyManual    = "manual"
+	SyncPolicyAutomated = "automated"
+)
+
+// ApplicationOptions describes argocd application created by the installer
+type ApplicationOptions struct {
+	Name                 string `yaml:"name"`
+	Project              string `yaml:"project"`
+	RepoUrl              string `yaml:"repoUrl"`
+	Path                 string `yaml:"path"`
+	Revision             string `yaml:"revision"`
+	DestinationServer    string `yaml:"destinationServer"`
+	DestinationNamespace string `yaml:"destinationNamespace"`
+	// SyncPolicy is manual or automated
+	SyncPolicy string `yaml:"syncPolicy"`
+	Prune      bool   `yaml:"prune"`
+	SelfHeal   bool   `yaml:"selfHeal"`
+}
+
+// BootstrapOptions describes the root application of app-of-apps
+type BootstrapOptions struct {
+	Name     string `yaml:"name"`
+	Project  string `yaml:"project"`
+	RepoUrl  string `yaml:"repoUrl"`
+	Path     string `yaml:"path"`
+	Revision string `yaml:"revision"`
+}
+
+// ProjectOptions describes argocd AppProject, projects are declared in the answers file only
+type ProjectOptions struct {
+	Name                     string               `yaml:"name"`
+	Description              string               `yaml:"description"`
+	SourceRepos              []string             `yaml:"sourceRepos"`
+	Destinations             []ProjectDestination `yaml:"destinations"`
+	ClusterResourceWhitelist []ProjectResource    `yaml:"clusterResourceWhitelist"`
+	Roles                    []ProjectRole        `yaml:"roles"`
+}
+
+type ProjectDestination struct {
+	Server    string `yaml:"server"`
+	Namespace string `yaml:"namespace"`
+}
+
+type ProjectResource struct {
+	Group string `yaml:"group"`
+	Kind  string `yaml:"kind"`
+}
+
+type ProjectRole struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	// Policies are casbin policy lines, e.g. "p, proj:team:ci, applications, sync, team/*, allow"
+	Policies []string `yaml:"policies"`
+	Groups   []string `yaml:"groups"`
+	// TokenSecret is the secret the role JWT token is stored in, token is not created when it is empty
+	TokenSecret string `yaml:"tokenSecret"`
+}
+
+// RbacOptions is merged into argocd-rbac-cm
+type RbacOptions struct {
+	// PolicyFile is the path to policy csv, its content is appended to Policy
+	PolicyFile  string   `yaml:"policyFile"`
+	Policy      string   `yaml:"policy"`
+	DefaultRole string   `yaml:"defaultRole"`
+	Scopes      []string `yaml:"scopes"`
+}
+
+// SsoOptions configures argocd login with OIDC provider or Dex connectors
+type SsoOptions struct {
+	Oidc OidcOptions `yaml:"oidc"`
+	// DexConfigFile is the path to dex.config yaml, it's read into DexConfig
+	DexConfigFile string `yaml:"dexConfigFile"`
+	DexConfig     string `yaml:"dexConfig"`
+}
+
+type OidcOptions struct {
+	Name     string `yaml:"name"`
+	Issuer   string `yaml:"issuer"`
+	ClientID string `yaml:"clientID"`
+	// ClientSecret is stored in argocd-secret
+	ClientSecret string `yaml:"clientSecret"`
+	// ClientSecretRef references existing secret as <secret>:<key> instead of ClientSecret
+	ClientSecretRef string   `yaml:"clientSecretRef"`
+	RequestedScopes []string `yaml:"requestedScopes"`
+	GroupsClaim     string   `yaml:"groupsClaim"`
+}
+
+// GithubAppOptions are github app credentials of manifest repos
+type GithubAppOptions struct {
+	AppID          int64  `yaml:"appID"`
+	InstallationID int64  `yaml:"installationID"`
+	PrivateKey     string `yaml:"privateKey"`
+	// PrivateKeyFile is the path to the app private key, it's read into PrivateKey
+	PrivateKeyFile string `yaml:"privateKeyFile"`
+	// EnterpriseBaseUrl is the api url of github enterprise, e.g. https://github.example.com/api/v3
+	EnterpriseBaseUrl string `yaml:"enterpriseBaseUrl"`
+}
+
 type CmdOptions struct {
 	Git struct {
 		Auth struct {
-			Type string
-			Pass string
-		}
-		Integration string
-		RepoUrl     string
-	}
+			Type          string           `yaml:"type"`
+			Username      string           `yaml:"username"`
+			Pass          string           `yaml:"pass"`
+			SshPrivateKey string           `yaml:"sshPrivateKey"`
+			GithubApp     GithubAppOptions `yaml:"githubApp"`
+		} `yaml:"auth"`
+		// CredsTemplateUrl is the url prefix of argocd repository credentials template
+		CredsTemplateUrl string `yaml:"credsTemplateUrl"`
+		// SshKeyFile is the path to ssh private key used instead of the git context key
+		SshKeyFile string `yaml:"sshKeyFile"`
+		// KnownHostsFile is the path to ssh_known_hosts file merged into argocd-ssh-known-hosts-cm
+		KnownHostsFile string `yaml:"knownHostsFile"`
+		// HostFingerprints are pinned host key fingerprints as host=SHA256:..., matching keys are scanned into known hosts
+		HostFingerprints []string `yaml:"hostFingerprints"`
+		Integration      string   `yaml:"integration"`
+		RepoUrl          string   `yaml:"repoUrl"`
+		// SkipRepoCheck skips listing the repo branches before it is added, e.g. when the repo is reachable from the cluster only
+		SkipRepoCheck bool `yaml:"skipRepoCheck"`
+		// AddRepo answers "Would you like to integrate git context for manifest repo"
+		AddRepo *bool `yaml:"addRepo"`
+	} `yaml:"git"`
 
 	Host struct {
-		HttpProxy  string
-		HttpsProxy string
-	}
+		HttpProxy  string `yaml:"httpProxy"`
+		HttpsProxy string `yaml:"httpsProxy"`
+	} `yaml:"host"`
 
 	Codefresh struct {
-		Host   string
-		Suffix string
+		Host   string `yaml:"host"`
+		Suffix string `yaml:"suffix"`
 		Auth   struct {
-			Token string
-		}
-		Clusters []string
-	}
+			Token string `yaml:"token"`
+		} `yaml:"auth"`
+		Clusters []string `yaml:"clusters"`
+		// ImportClusters answers "Would you like to integrate clusters from your account"
+		ImportClusters *bool `yaml:"importClusters"`
+	} `yaml:"codefresh"`
 
 	Kube struct {
-		ManifestPath string
-		Namespace    string
-		Context      string
-		ConfigPath   string
-		InCluster    bool
-	}
+		ManifestPath string `yaml:"manifestPath"`
+		Namespace    string `yaml:"namespace"`
+		Context      string `yaml:"context"`
+		ConfigPath   string `yaml:"configPath"`
+		InCluster    bool   `yaml:"inCluster"`
+	} `yaml:"kube"`
 	Argo struct {
-		Token    string
-		Host     string
-		Password string
-		Username string
-	}
+		Token    string `yaml:"token"`
+		Host     string `yaml:"host"`
+		Password string `yaml:"password"`
+		Username string `yaml:"username"`
+		// GeneratePassword creates a random admin password which satisfies the password policy
+		GeneratePassword bool `yaml:"generatePassword"`
+		// PasswordSecret is the name of the secret to store the admin password in
+		PasswordSecret string         `yaml:"passwordSecret"`
+		PasswordPolicy PasswordPolicy `yaml:"passwordPolicy"`
+		// DeclarativePassword writes the admin password hash into argocd-secret instead of changing it through the api
+		DeclarativePassword bool `yaml:"declarativePassword"`
+		// DeleteInitialPassword removes argocd-initial-admin-secret after the password is changed
+		DeleteInitialPassword bool `yaml:"deleteInitialPassword"`
+		// AgentAccount is the local account created for the agent, admin is used when it is empty
+		AgentAccount string `yaml:"agentAccount"`
+		// AgentToken is the api key of the agent account, it is generated during the installation
+		AgentToken string `yaml:"-"`
+		// AgentHost is the argocd address used by the agent, defaults to Host
+		AgentHost string `yaml:"agentHost"`
+	} `yaml:"argo"`
 
 	Controller struct {
-		LoadBalancer bool
-	}
+		LoadBalancer bool `yaml:"loadBalancer"`
+		// Expose is the way argocd-server is exposed: loadbalancer, ingress, nodeport, port-forward or none
+		Expose      string `yaml:"expose"`
+		PortForward struct {
+			LocalPort int `yaml:"localPort"`
+		} `yaml:"portForward"`
+		Ingress struct {
+			Host        string            `yaml:"host"`
+			TlsSecret   string            `yaml:"tlsSecret"`
+			Class       string            `yaml:"class"`
+			Annotations map[string]string `yaml:"annotations"`
+		} `yaml:"ingress"`
+	} `yaml:"controller"`
+
+	DefaultApp struct {
+		ApplicationOptions `yaml:",inline"`
+		// Skip disables creation of the default application
+		Skip bool `yaml:"skip"`
+	} `yaml:"defaultApp"`
+
+	Rbac      RbacOptions      `yaml:"rbac"`
+	Sso       SsoOptions       `yaml:"sso"`
+	Projects  []ProjectOptions `yaml:"projects"`
+	Bootstrap BootstrapOptions `yaml:"bootstrap"`
+	// ClusterApplicationSet is rolled out to every imported codefresh cluster, it's created when repo url is set
+	ClusterApplicationSet ApplicationOptions `yaml:"clusterApplicationSet"`
+
+	Installer struct {
+		DryRun bool `yaml:"dryRun"`
+		// Output is the format of the dry run plan, text or json
+		Output string `yaml:"output"`
+		// Restart ignores checkpoints of previous failed installation
+		Restart bool `yaml:"restart"`
+		// KeepOnFailure skips the rollback of failed installation
+		KeepOnFailure bool `yaml:"keepOnFailure"`
+		// WaitTimeout limits waiting for argocd workloads to become ready
+		WaitTimeout time.Duration `yaml:"waitTimeout"`
+	} `yaml:"installer"`
+
+	Questionnaire struct {
+		AnswersFile string
+		// Yes accepts the default answer for every question instead of prompting
+		Yes bool
+	} `yaml:"-"`
 }
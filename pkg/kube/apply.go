@@ -0,0 +1,179 @@
+package kube
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	apixv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
+	"k8s.io/client-go/dynamic"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+const (
+	// FieldManager owns the fields applied by the installer
+	FieldManager = "cf-gitops-controller"
+	// LastAppliedAnnotation keeps the applied configuration for three-way merge on servers without server-side apply
+	LastAppliedAnnotation = "gitops.codefresh.io/last-applied-configuration"
+)
+
+const (
+	ApplyCreated    = "created"
+	ApplyConfigured = "configured"
+	ApplyUnchanged  = "unchanged"
+)
+
+var applyScheme = runtime.NewScheme()
+
+func init() {
+	_ = clientgoscheme.AddToScheme(applyScheme)
+	_ = apixv1beta1.AddToScheme(applyScheme)
+}
+
+func toUnstructured(obj runtime.Object) (*unstructured.Unstructured, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u.DeepCopy(), nil
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{Object: content}
+	if u.GetKind() == "" {
+		kinds, _, err := applyScheme.ObjectKinds(obj)
+		if err != nil {
+			return nil, err
+		}
+		u.SetGroupVersionKind(kinds[0])
+	}
+	return u, nil
+}
+
+// resourceFor returns the dynamic client of the object resource
+func (k *kube) resourceFor(u *unstructured.Unstructured) (dynamic.ResourceInterface, error) {
+	gvk := u.GroupVersionKind()
+	mapping, err := k.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		// kind can be defined by just created CustomResourceDefinition
+		k.mapper.Reset()
+		mapping, err = k.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return k.dynamicClient.Resource(mapping.Resource), nil
+	}
+	if u.GetNamespace() == "" {
+		u.SetNamespace(k.namespace)
+	}
+	return k.dynamicClient.Resource(mapping.Resource).Namespace(u.GetNamespace()), nil
+}
+
+// ApplyObject converges the object in the cluster to the given state with server-side apply,
+// servers without server-side apply get three-way merge patch. Returns created, configured or unchanged
+func (k *kube) ApplyObject(obj runtime.Object) (string, error) {
+	u, err := toUnstructured(obj)
+	if err != nil {
+		return "", err
+	}
+	resource, err := k.resourceFor(u)
+	if err != nil {
+		return "", err
+	}
+
+	existing, err := resource.Get(u.GetName(), metav1.GetOptions{})
+	if k8sErrors.IsNotFound(err) {
+		existing = nil
+	} else if err != nil {
+		return "", err
+	}
+
+	applied, err := k.serverSideApply(resource, u)
+	if k8sErrors.IsUnsupportedMediaType(err) {
+		applied, err = k.threeWayMergeApply(resource, u, existing)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	description := fmt.Sprintf("%s \"%s\"", u.GetKind(), u.GetName())
+	if existing == nil {
+		k.journal.Record("delete "+description, func() error {
+			return resource.Delete(u.GetName(), &metav1.DeleteOptions{})
+		})
+		return ApplyCreated, nil
+	}
+	if applied.GetResourceVersion() == existing.GetResourceVersion() {
+		return ApplyUnchanged, nil
+	}
+	k.journal.Record("restore "+description, func() error {
+		current, err := resource.Get(u.GetName(), metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		previous := existing.DeepCopy()
+		previous.SetResourceVersion(current.GetResourceVersion())
+		previous.SetManagedFields(nil)
+		_, err = resource.Update(previous, metav1.UpdateOptions{})
+		return err
+	})
+	return ApplyConfigured, nil
+}
+
+func (k *kube) serverSideApply(resource dynamic.ResourceInterface, u *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	data, err := json.Marshal(u)
+	if err != nil {
+		return nil, err
+	}
+	force := true
+	return resource.Patch(u.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{
+		FieldManager: FieldManager,
+		Force:        &force,
+	})
+}
+
+func (k *kube) threeWayMergeApply(resource dynamic.ResourceInterface, u *unstructured.Unstructured, existing *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	desired := u.DeepCopy()
+	modified, err := json.Marshal(desired)
+	if err != nil {
+		return nil, err
+	}
+	annotations := desired.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[LastAppliedAnnotation] = string(modified)
+	desired.SetAnnotations(annotations)
+
+	if existing == nil {
+		return resource.Create(desired, metav1.CreateOptions{})
+	}
+
+	modified, err = json.Marshal(desired)
+	if err != nil {
+		return nil, err
+	}
+	original := []byte(existing.GetAnnotations()[LastAppliedAnnotation])
+	if len(original) == 0 {
+		original = []byte("{}")
+	}
+	current, err := json.Marshal(existing)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := jsonmergepatch.CreateThreeWayJSONMergePatch(original, modified, current)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Can't create merge patch for %s \"%s\": %v", u.GetKind(), u.GetName(), err))
+	}
+	if string(patch) == "{}" {
+		return existing, nil
+	}
+	return resource.Patch(u.GetName(), types.MergePatchType, patch, metav1.PatchOptions{FieldManager: FieldManager})
+}
@@ -0,0 +1,202 @@
+package kube
+
+import (
+	"github.com/codefresh-io/cf-gitops-controller/pkg/rollback"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery/cached/memory"
+	discoveryfake "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/restmapper"
+	k8stesting "k8s.io/client-go/testing"
+	"reflect"
+	"testing"
+)
+
+var configMapsResource = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+func configMap(resourceVersion string, data map[string]interface{}, annotations map[string]string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "argocd-cm", "namespace": "argocd"},
+		"data":       data,
+	}}
+	u.SetResourceVersion(resourceVersion)
+	u.SetAnnotations(annotations)
+	return u
+}
+
+// newApplyClient returns kube client of the fake cluster with the objects, patch of the
+// server-side apply is answered by serverSideApply
+func newApplyClient(serverSideApply k8stesting.ReactionFunc, objects ...runtime.Object) (*kube, *dynamicfake.FakeDynamicClient) {
+	scheme := runtime.NewScheme()
+	tracker := k8stesting.NewObjectTracker(scheme, serializer.NewCodecFactory(scheme).UniversalDecoder())
+	for _, obj := range objects {
+		_ = tracker.Add(obj)
+	}
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(scheme)
+	dynamicClient.PrependReactor("*", "*", k8stesting.ObjectReaction(tracker))
+	dynamicClient.PrependReactor("patch", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.(k8stesting.PatchAction).GetPatchType() == types.ApplyPatchType {
+			return serverSideApply(action)
+		}
+		// fake tracker keeps resource version, the server changes it on every update
+		handled, obj, err := k8stesting.ObjectReaction(tracker)(action)
+		if err == nil {
+			accessor, _ := meta.Accessor(obj)
+			accessor.SetResourceVersion("2")
+		}
+		return handled, obj, err
+	})
+	discovery := &discoveryfake.FakeDiscovery{Fake: &k8stesting.Fake{Resources: []*metav1.APIResourceList{{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{{Name: "configmaps", Kind: "ConfigMap", Namespaced: true}},
+	}}}}
+	return &kube{
+		namespace:     "argocd",
+		journal:       rollback.New(),
+		dynamicClient: dynamicClient,
+		mapper:        restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discovery)),
+	}, dynamicClient
+}
+
+// appliedAs answers server-side apply with the object
+func appliedAs(obj *unstructured.Unstructured) k8stesting.ReactionFunc {
+	return func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, obj, nil
+	}
+}
+
+func unsupportedApply(action k8stesting.Action) (bool, runtime.Object, error) {
+	return true, nil, &k8sErrors.StatusError{ErrStatus: metav1.Status{
+		Status: metav1.StatusFailure,
+		Code:   415,
+		Reason: metav1.StatusReasonUnsupportedMediaType,
+	}}
+}
+
+func TestApplyObjectServerSide(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		applied  *unstructured.Unstructured
+		want     string
+	}{
+		{
+			name:    "created",
+			applied: configMap("1", map[string]interface{}{"url": "https://argocd.example.com"}, nil),
+			want:    ApplyCreated,
+		},
+		{
+			name:     "configured when resource version changes",
+			existing: []runtime.Object{configMap("1", map[string]interface{}{"url": "https://old.example.com"}, nil)},
+			applied:  configMap("2", map[string]interface{}{"url": "https://argocd.example.com"}, nil),
+			want:     ApplyConfigured,
+		},
+		{
+			name:     "unchanged when resource version is kept",
+			existing: []runtime.Object{configMap("1", map[string]interface{}{"url": "https://argocd.example.com"}, nil)},
+			applied:  configMap("1", map[string]interface{}{"url": "https://argocd.example.com"}, nil),
+			want:     ApplyUnchanged,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newApplyClient(appliedAs(tt.applied), tt.existing...)
+
+			got, err := client.ApplyObject(configMap("", map[string]interface{}{"url": "https://argocd.example.com"}, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("ApplyObject() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyObjectThreeWayMerge(t *testing.T) {
+	desired := configMap("", map[string]interface{}{"url": "https://argocd.example.com"}, nil)
+
+	t.Run("created with last applied configuration and deleted on rollback", func(t *testing.T) {
+		client, dynamicClient := newApplyClient(unsupportedApply)
+
+		got, err := client.ApplyObject(desired)
+		if err != nil || got != ApplyCreated {
+			t.Fatalf("ApplyObject() = %q, %v", got, err)
+		}
+		created, err := dynamicClient.Resource(configMapsResource).Namespace("argocd").Get("argocd-cm", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if created.GetAnnotations()[LastAppliedAnnotation] == "" {
+			t.Errorf("annotations = %v, want last applied configuration", created.GetAnnotations())
+		}
+
+		err = client.journal.Rollback()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = dynamicClient.Resource(configMapsResource).Namespace("argocd").Get("argocd-cm", metav1.GetOptions{})
+		if !k8sErrors.IsNotFound(err) {
+			t.Errorf("config map is not deleted on rollback: %v", err)
+		}
+	})
+
+	t.Run("configured keeps fields of other managers and is restored on rollback", func(t *testing.T) {
+		lastApplied := `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"argocd-cm","namespace":"argocd"},"data":{"url":"https://old.example.com","users.anonymous.enabled":"true"}}`
+		existing := configMap("1", map[string]interface{}{
+			"url":                     "https://old.example.com",
+			"users.anonymous.enabled": "true",
+			"admin.enabled":           "false",
+		}, map[string]string{LastAppliedAnnotation: lastApplied})
+		client, dynamicClient := newApplyClient(unsupportedApply, existing)
+
+		got, err := client.ApplyObject(desired)
+		if err != nil || got != ApplyConfigured {
+			t.Fatalf("ApplyObject() = %q, %v", got, err)
+		}
+		configured, err := dynamicClient.Resource(configMapsResource).Namespace("argocd").Get("argocd-cm", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// field removed from the applied configuration is deleted, field set by someone else is kept
+		wantData := map[string]interface{}{"url": "https://argocd.example.com", "admin.enabled": "false"}
+		if !reflect.DeepEqual(configured.Object["data"], wantData) {
+			t.Errorf("data = %v, want %v", configured.Object["data"], wantData)
+		}
+
+		err = client.journal.Rollback()
+		if err != nil {
+			t.Fatal(err)
+		}
+		restored, err := dynamicClient.Resource(configMapsResource).Namespace("argocd").Get("argocd-cm", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(restored.Object["data"], existing.Object["data"]) {
+			t.Errorf("data after rollback = %v, want %v", restored.Object["data"], existing.Object["data"])
+		}
+	})
+
+	t.Run("unchanged when there is nothing to patch", func(t *testing.T) {
+		client, _ := newApplyClient(unsupportedApply)
+		_, err := client.ApplyObject(desired)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := client.ApplyObject(desired)
+		if err != nil || got != ApplyUnchanged {
+			t.Errorf("ApplyObject() = %q, %v", got, err)
+		}
+	})
+}
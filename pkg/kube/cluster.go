@@ -0,0 +1,34 @@
+package kube
+
+import (
+	"errors"
+	"fmt"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"strings"
+)
+
+// ArgoClusterSecretSelector selects the secrets argocd keeps registered clusters in
+const ArgoClusterSecretSelector = "argocd.argoproj.io/secret-type=cluster"
+
+// LabelClusterSecret adds labels to the secret of cluster registered in argocd, e.g. for ApplicationSet cluster generator
+func (k *kube) LabelClusterSecret(server string, labels map[string]string) error {
+	secrets, err := k.clientSet.CoreV1().Secrets(k.namespace).List(metav1.ListOptions{LabelSelector: ArgoClusterSecretSelector})
+	if err != nil {
+		return err
+	}
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		if strings.TrimSuffix(string(secret.Data["server"]), "/") != strings.TrimSuffix(server, "/") {
+			continue
+		}
+		if secret.Labels == nil {
+			secret.Labels = map[string]string{}
+		}
+		for key, value := range labels {
+			secret.Labels[key] = value
+		}
+		_, err = k.clientSet.CoreV1().Secrets(k.namespace).Update(secret)
+		return err
+	}
+	return errors.New(fmt.Sprintf("Secret of cluster \"%s\" not found", server))
+}
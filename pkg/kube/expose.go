@@ -0,0 +1,191 @@
+package kube
+
+import (
+	"errors"
+	"fmt"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
+	core "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"strings"
+)
+
+// ArgoServerIngressName is the name of the ingress created for argocd-server
+const ArgoServerIngressName = "argocd-server"
+
+const (
+	IngressV1      = "networking.k8s.io/v1"
+	IngressV1beta1 = "networking.k8s.io/v1beta1"
+)
+
+// defaultIngressAnnotations pass TLS through nginx ingress to argocd-server, which serves both UI and gRPC on https port
+var defaultIngressAnnotations = map[string]string{
+	"nginx.ingress.kubernetes.io/ssl-passthrough":    "true",
+	"nginx.ingress.kubernetes.io/force-ssl-redirect": "true",
+	"nginx.ingress.kubernetes.io/backend-protocol":   "HTTPS",
+}
+
+// IngressApiVersion returns networking.k8s.io/v1 when the cluster serves ingresses with it (kubernetes 1.19+),
+// networking.k8s.io/v1beta1 otherwise (kubernetes 1.14 - 1.21)
+func (k *kube) IngressApiVersion() (string, error) {
+	resources, err := k.clientSet.Discovery().ServerResourcesForGroupVersion(IngressV1)
+	if k8sErrors.IsNotFound(err) {
+		return IngressV1beta1, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	for _, resource := range resources.APIResources {
+		if resource.Name == "ingresses" {
+			return IngressV1, nil
+		}
+	}
+	return IngressV1beta1, nil
+}
+
+// NewArgoServerIngress returns ingress of the api version which exposes argocd-server on the given host,
+// ingress class is spec.ingressClassName of networking.k8s.io/v1 and the class annotation of v1beta1
+func NewArgoServerIngress(apiVersion string, namespace string, host string, tlsSecret string, class string, annotations map[string]string) *unstructured.Unstructured {
+	ingressAnnotations := map[string]interface{}{}
+	if len(annotations) == 0 {
+		annotations = defaultIngressAnnotations
+	}
+	for key, value := range annotations {
+		ingressAnnotations[key] = value
+	}
+
+	path := map[string]interface{}{
+		"backend": map[string]interface{}{
+			"serviceName": "argocd-server",
+			"servicePort": "https",
+		},
+	}
+	spec := map[string]interface{}{}
+	if apiVersion == IngressV1 {
+		path = map[string]interface{}{
+			"path":     "/",
+			"pathType": "Prefix",
+			"backend": map[string]interface{}{
+				"service": map[string]interface{}{
+					"name": "argocd-server",
+					"port": map[string]interface{}{"name": "https"},
+				},
+			},
+		}
+		if class != "" {
+			spec["ingressClassName"] = class
+		}
+	} else if class != "" {
+		ingressAnnotations["kubernetes.io/ingress.class"] = class
+	}
+	spec["rules"] = []interface{}{
+		map[string]interface{}{
+			"host": host,
+			"http": map[string]interface{}{
+				"paths": []interface{}{path},
+			},
+		},
+	}
+	if tlsSecret != "" {
+		spec["tls"] = []interface{}{
+			map[string]interface{}{
+				"hosts":      []interface{}{host},
+				"secretName": tlsSecret,
+			},
+		}
+	}
+
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       "Ingress",
+		"metadata": map[string]interface{}{
+			"name":        ArgoServerIngressName,
+			"namespace":   namespace,
+			"annotations": ingressAnnotations,
+		},
+		"spec": spec,
+	}}
+}
+
+// GetArgoServerHost resolves argocd url from the way argocd-server is exposed
+func (k *kube) GetArgoServerHost(expose string) (string, error) {
+	switch expose {
+	case install.ExposeLoadBalancer, "":
+		svc, err := k.GetArgoServerSvc(k.namespace)
+		if err != nil {
+			return "", err
+		}
+		return k.GetLoadBalancerHost(svc)
+	case install.ExposeNodePort:
+		return k.getNodePortHost()
+	case install.ExposeIngress:
+		return k.getIngressHost()
+	}
+	return "", errors.New(fmt.Sprintf("Argocd host can't be resolved when argocd is exposed with \"%s\", use --argo-host", expose))
+}
+
+func (k *kube) getNodePortHost() (string, error) {
+	svc, err := k.GetArgoServerSvc(k.namespace)
+	if err != nil {
+		return "", err
+	}
+	var nodePort int32
+	for _, port := range svc.Spec.Ports {
+		if port.Name == "https" || (nodePort == 0 && port.Port == 443) {
+			nodePort = port.NodePort
+		}
+	}
+	if nodePort == 0 {
+		return "", errors.New(fmt.Sprint("Failed to resolve argocd-server node port"))
+	}
+
+	nodes, err := k.clientSet.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	var address string
+	for _, node := range nodes.Items {
+		for _, nodeAddress := range node.Status.Addresses {
+			if nodeAddress.Type == core.NodeExternalIP {
+				return fmt.Sprintf("https://%s:%d", nodeAddress.Address, nodePort), nil
+			}
+			if nodeAddress.Type == core.NodeInternalIP && address == "" {
+				address = nodeAddress.Address
+			}
+		}
+	}
+	if address == "" {
+		return "", errors.New(fmt.Sprint("Failed to resolve node address"))
+	}
+	return fmt.Sprintf("https://%s:%d", address, nodePort), nil
+}
+
+func (k *kube) getIngressHost() (string, error) {
+	apiVersion, err := k.IngressApiVersion()
+	if err != nil {
+		return "", err
+	}
+	resource := schema.GroupVersionResource{Group: "networking.k8s.io", Version: strings.TrimPrefix(apiVersion, "networking.k8s.io/"), Resource: "ingresses"}
+	ingress, err := k.dynamicClient.Resource(resource).Namespace(k.namespace).Get(ArgoServerIngressName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	rules, _, _ := unstructured.NestedSlice(ingress.Object, "spec", "rules")
+	for _, rule := range rules {
+		if host, _, _ := unstructured.NestedString(rule.(map[string]interface{}), "host"); host != "" {
+			return "https://" + host, nil
+		}
+	}
+	lbIngresses, _, _ := unstructured.NestedSlice(ingress.Object, "status", "loadBalancer", "ingress")
+	for _, lbIngress := range lbIngresses {
+		if hostname, _, _ := unstructured.NestedString(lbIngress.(map[string]interface{}), "hostname"); hostname != "" {
+			return "https://" + hostname, nil
+		}
+		if ip, _, _ := unstructured.NestedString(lbIngress.(map[string]interface{}), "ip"); ip != "" {
+			return "https://" + ip, nil
+		}
+	}
+	return "", errors.New(fmt.Sprint("Failed to resolve ingress Hostname or IP"))
+}
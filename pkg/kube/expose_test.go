@@ -0,0 +1,82 @@
+package kube
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"reflect"
+	"testing"
+)
+
+func TestNewArgoServerIngress(t *testing.T) {
+	tests := []struct {
+		name            string
+		apiVersion      string
+		class           string
+		annotations     map[string]string
+		wantClassName   string
+		wantAnnotations map[string]string
+		wantBackend     map[string]interface{}
+	}{
+		{
+			name:            "v1 ingress class is spec field",
+			apiVersion:      IngressV1,
+			class:           "nginx",
+			annotations:     map[string]string{"example.com/owner": "gitops"},
+			wantClassName:   "nginx",
+			wantAnnotations: map[string]string{"example.com/owner": "gitops"},
+			wantBackend: map[string]interface{}{
+				"service": map[string]interface{}{
+					"name": "argocd-server",
+					"port": map[string]interface{}{"name": "https"},
+				},
+			},
+		},
+		{
+			name:            "v1beta1 ingress class is annotation",
+			apiVersion:      IngressV1beta1,
+			class:           "nginx",
+			annotations:     map[string]string{"example.com/owner": "gitops"},
+			wantAnnotations: map[string]string{"example.com/owner": "gitops", "kubernetes.io/ingress.class": "nginx"},
+			wantBackend:     map[string]interface{}{"serviceName": "argocd-server", "servicePort": "https"},
+		},
+		{
+			name:            "ssl passthrough annotations by default",
+			apiVersion:      IngressV1,
+			wantAnnotations: defaultIngressAnnotations,
+			wantBackend: map[string]interface{}{
+				"service": map[string]interface{}{
+					"name": "argocd-server",
+					"port": map[string]interface{}{"name": "https"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ingress := NewArgoServerIngress(tt.apiVersion, "argocd", "argocd.example.com", "argocd-tls", tt.class, tt.annotations)
+
+			if ingress.GetAPIVersion() != tt.apiVersion || ingress.GetNamespace() != "argocd" || ingress.GetName() != ArgoServerIngressName {
+				t.Errorf("ingress = %s %s/%s", ingress.GetAPIVersion(), ingress.GetNamespace(), ingress.GetName())
+			}
+			className, _, _ := unstructured.NestedString(ingress.Object, "spec", "ingressClassName")
+			if className != tt.wantClassName {
+				t.Errorf("ingressClassName = %q, want %q", className, tt.wantClassName)
+			}
+			if !reflect.DeepEqual(ingress.GetAnnotations(), tt.wantAnnotations) {
+				t.Errorf("annotations = %v, want %v", ingress.GetAnnotations(), tt.wantAnnotations)
+			}
+			rules, _, _ := unstructured.NestedSlice(ingress.Object, "spec", "rules")
+			if len(rules) != 1 || rules[0].(map[string]interface{})["host"] != "argocd.example.com" {
+				t.Fatalf("rules = %v", rules)
+			}
+			paths, _, _ := unstructured.NestedSlice(rules[0].(map[string]interface{}), "http", "paths")
+			if len(paths) != 1 || !reflect.DeepEqual(paths[0].(map[string]interface{})["backend"], tt.wantBackend) {
+				t.Errorf("paths = %v, want backend %v", paths, tt.wantBackend)
+			}
+			tls, _, _ := unstructured.NestedSlice(ingress.Object, "spec", "tls")
+			if len(tls) != 1 || tls[0].(map[string]interface{})["secretName"] != "argocd-tls" {
+				t.Errorf("tls = %v", tls)
+			}
+		})
+	}
+}
@@ -3,25 +3,40 @@ package kube
 import (
 	"errors"
 	"fmt"
-	"github.com/codefresh-io/argocd-listener/installer/pkg/logger"
 	"github.com/codefresh-io/argocd-listener/installer/pkg/obj/kubeobj"
 	"github.com/codefresh-io/argocd-listener/installer/pkg/templates"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/checkpoint"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/logger"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/rollback"
 	"io/ioutil"
 	core "k8s.io/api/core/v1"
 	apixv1beta1client "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/typed/apiextensions/v1beta1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	"net/http"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 )
 
+var clusterScopedKinds = map[string]bool{
+	"Namespace":                true,
+	"CustomResourceDefinition": true,
+	"ClusterRole":              true,
+	"ClusterRoleBinding":       true,
+}
+
 type (
 	Kube interface {
 		GetNamespaces() ([]string, error)
@@ -31,12 +46,24 @@ type (
 		GetLoadBalancerHost(svc core.Service) (string, error)
 
 		CreateNamespace(string) error
+		UseNamespace(string)
 		GetService(string) (*core.Service, error)
 		UpdateService(*core.Service) error
-		GetAutogeneratedPassword() (string, error)
+		GetAutogeneratedPassword(time.Duration) (string, error)
+		DeleteInitialAdminSecret() error
+		SetAdminPassword(string) error
 		CreateObjects(string) error
+		ApplyObject(runtime.Object) (string, error)
+		WaitForWorkloads(string, time.Duration) error
 		DeleteObjects(string) error
-		GetArgoServerHost() (string, error)
+		GetArgoServerHost(string) (string, error)
+		IngressApiVersion() (string, error)
+		PortForwardArgoServer(int) (string, func(), error)
+		GetConfigMapData(string) (map[string]string, error)
+		SaveConfigMapData(string, map[string]string) error
+		GetSecretData(string) (map[string]string, error)
+		LabelClusterSecret(string, map[string]string) error
+		SaveSecretData(string, map[string]string) error
 	}
 
 	kube struct {
@@ -44,8 +71,12 @@ type (
 		namespace        string
 		pathToKubeConfig string
 		inCluster        bool
+		journal          *rollback.Journal
+		restConfig       *rest.Config
 		clientSet        *kubernetes.Clientset
 		crdClientSet     *apixv1beta1client.ApiextensionsV1beta1Client
+		dynamicClient    dynamic.Interface
+		mapper           *restmapper.DeferredDiscoveryRESTMapper
 	}
 
 	Options struct {
@@ -54,6 +85,8 @@ type (
 		PathToKubeConfig string
 		InCluster        bool
 		FailFast         bool
+		// Journal records the rollback of every mutating call, optional
+		Journal *rollback.Journal
 	}
 )
 
@@ -63,27 +96,21 @@ func New(o *Options) (Kube, error) {
 		namespace:        o.Namespace,
 		pathToKubeConfig: o.PathToKubeConfig,
 		inCluster:        o.InCluster,
+		journal:          o.Journal,
 	}
 
-	clientSet, crdClientSet, err := client.buildClient()
+	err := client.buildClient()
 
 	if err != nil && !o.FailFast {
 		return nil, err
 	}
 
-	client.clientSet = clientSet
-	client.crdClientSet = crdClientSet
-
 	return client, nil
 }
 
-func (k *kube) GetAutogeneratedPassword() (string, error) {
-	opts := metav1.ListOptions{LabelSelector: "app.kubernetes.io/name=argocd-server"}
-	podsList, err := k.clientSet.CoreV1().Pods(k.namespace).List(opts)
-	if err != nil {
-		return "", err
-	}
-	return podsList.Items[0].ObjectMeta.Name, nil
+// UseNamespace points the client to an existing namespace without creating it
+func (k *kube) UseNamespace(namespaceName string) {
+	k.namespace = namespaceName
 }
 
 func (k *kube) CreateNamespace(namespaceName string) error {
@@ -94,11 +121,24 @@ func (k *kube) CreateNamespace(namespaceName string) error {
 	}
 	var namespaceObj = core.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: k.namespace,
+			Name: namespaceName,
 		},
 	}
 	_, err = k.clientSet.CoreV1().Namespaces().Create(&namespaceObj)
-	return err
+	if err != nil {
+		return err
+	}
+	k.namespace = namespaceName
+	k.journal.Record(fmt.Sprintf("delete namespace \"%s\"", namespaceName), func() error {
+		// checkpoints live in the namespace, it's kept while they are needed to resume the installation
+		_, err := k.clientSet.CoreV1().ConfigMaps(namespaceName).Get(checkpoint.ConfigMapName, metav1.GetOptions{})
+		if err == nil {
+			logger.Warning(fmt.Sprintf("Namespace \"%s\" is kept, it has checkpoints of the installation", namespaceName))
+			return nil
+		}
+		return k.clientSet.CoreV1().Namespaces().Delete(namespaceName, &metav1.DeleteOptions{})
+	})
+	return nil
 }
 
 func (k *kube) GetService(labelSelector string) (*core.Service, error) {
@@ -118,20 +158,135 @@ func (k *kube) GetService(labelSelector string) (*core.Service, error) {
 }
 
 func (k *kube) UpdateService(svc *core.Service) error {
-	_, err := k.clientSet.CoreV1().Services(k.namespace).Update(svc)
+	previous, err := k.clientSet.CoreV1().Services(k.namespace).Get(svc.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	_, err = k.clientSet.CoreV1().Services(k.namespace).Update(svc)
+	if err != nil {
+		return err
+	}
+	k.journal.Record(fmt.Sprintf("restore service \"%s\" type %s", svc.Name, previous.Spec.Type), func() error {
+		return k.restoreServiceType(svc.Name, previous.Spec.Type)
+	})
+	return nil
+}
+
+func (k *kube) restoreServiceType(name string, serviceType core.ServiceType) error {
+	svc, err := k.clientSet.CoreV1().Services(k.namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	svc.Spec.Type = serviceType
+	if serviceType == core.ServiceTypeClusterIP {
+		for i := range svc.Spec.Ports {
+			svc.Spec.Ports[i].NodePort = 0
+		}
+	}
+	_, err = k.clientSet.CoreV1().Services(k.namespace).Update(svc)
 	return err
 }
 
-func IsLoadBalancer(svc core.Service) bool {
-	return svc.Spec.Type == "LoadBalancer"
+// GetConfigMapData returns data of the config map in the installation namespace
+func (k *kube) GetConfigMapData(name string) (map[string]string, error) {
+	configMap, err := k.clientSet.CoreV1().ConfigMaps(k.namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return configMap.Data, nil
 }
 
-func (k *kube) GetArgoServerHost() (string, error) {
-	svc, err := k.GetArgoServerSvc(k.namespace)
+// SaveConfigMapData replaces data of the config map, the config map is created if it doesn't exist
+func (k *kube) SaveConfigMapData(name string, data map[string]string) error {
+	configMap, err := k.clientSet.CoreV1().ConfigMaps(k.namespace).Get(name, metav1.GetOptions{})
+	if k8sErrors.IsNotFound(err) {
+		_, err = k.clientSet.CoreV1().ConfigMaps(k.namespace).Create(&core.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: k.namespace},
+			Data:       data,
+		})
+		if err != nil {
+			return err
+		}
+		k.journal.Record(fmt.Sprintf("delete config map \"%s\"", name), func() error {
+			return k.clientSet.CoreV1().ConfigMaps(k.namespace).Delete(name, &metav1.DeleteOptions{})
+		})
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	previousData := configMap.Data
+	configMap.Data = data
+	_, err = k.clientSet.CoreV1().ConfigMaps(k.namespace).Update(configMap)
 	if err != nil {
-		return "", err
+		return err
+	}
+	k.journal.Record(fmt.Sprintf("restore config map \"%s\"", name), func() error {
+		configMap, err := k.clientSet.CoreV1().ConfigMaps(k.namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		configMap.Data = previousData
+		_, err = k.clientSet.CoreV1().ConfigMaps(k.namespace).Update(configMap)
+		return err
+	})
+	return nil
+}
+
+// GetSecretData returns decoded data of the secret in the installation namespace
+func (k *kube) GetSecretData(name string) (map[string]string, error) {
+	secret, err := k.clientSet.CoreV1().Secrets(k.namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	data := make(map[string]string)
+	for key, value := range secret.Data {
+		data[key] = string(value)
+	}
+	return data, nil
+}
+
+// SaveSecretData replaces data of the secret, the secret is created if it doesn't exist
+func (k *kube) SaveSecretData(name string, data map[string]string) error {
+	secret, err := k.clientSet.CoreV1().Secrets(k.namespace).Get(name, metav1.GetOptions{})
+	if k8sErrors.IsNotFound(err) {
+		_, err = k.clientSet.CoreV1().Secrets(k.namespace).Create(&core.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: k.namespace},
+			Type:       core.SecretTypeOpaque,
+			StringData: data,
+		})
+		if err != nil {
+			return err
+		}
+		k.journal.Record(fmt.Sprintf("delete secret \"%s\"", name), func() error {
+			return k.clientSet.CoreV1().Secrets(k.namespace).Delete(name, &metav1.DeleteOptions{})
+		})
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	previousData := secret.Data
+	secret.Data = nil
+	secret.StringData = data
+	_, err = k.clientSet.CoreV1().Secrets(k.namespace).Update(secret)
+	if err != nil {
+		return err
 	}
-	return k.GetLoadBalancerHost(svc)
+	k.journal.Record(fmt.Sprintf("restore secret \"%s\"", name), func() error {
+		secret, err := k.clientSet.CoreV1().Secrets(k.namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		secret.Data = previousData
+		_, err = k.clientSet.CoreV1().Secrets(k.namespace).Update(secret)
+		return err
+	})
+	return nil
+}
+
+func IsLoadBalancer(svc core.Service) bool {
+	return svc.Spec.Type == "LoadBalancer"
 }
 
 func (k *kube) GetLoadBalancerHost(svc core.Service) (string, error) {
@@ -150,7 +305,7 @@ func (k *kube) GetLoadBalancerHost(svc core.Service) (string, error) {
 	return "", errors.New(fmt.Sprint("Failed to retrieve Load Balancer Hostname or IP"))
 }
 
-func (k *kube) buildClient() (*kubernetes.Clientset, *apixv1beta1client.ApiextensionsV1beta1Client, error) {
+func (k *kube) buildClient() error {
 	var config *rest.Config
 	var err error
 	if k.inCluster {
@@ -167,11 +322,23 @@ func (k *kube) buildClient() (*kubernetes.Clientset, *apixv1beta1client.Apiexten
 	}
 
 	if err != nil {
-		return nil, nil, err
+		return err
 	}
-	clientSet, err := kubernetes.NewForConfig(config)
-	apixClient, err := apixv1beta1client.NewForConfig(config)
-	return clientSet, apixClient, err
+	k.restConfig = config
+	k.clientSet, err = kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	k.crdClientSet, err = apixv1beta1client.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	k.dynamicClient, err = dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	k.mapper = restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(k.clientSet.Discovery()))
+	return nil
 }
 
 func (k *kube) GetArgoServerSvc(namespace string) (core.Service, error) {
@@ -245,56 +412,51 @@ func GetAllContexts(pathToKubeConfig string) ([]string, error) {
 }
 
 func (k *kube) CreateObjects(manifestPath string) error {
-	var err error
-	templatesMap, err := buildTemplatesFromManifest(manifestPath)
-	if err != nil {
-		return err
-	}
-	var templatesValues map[string]interface{}
-
-	kubeObjects, _, err := templates.KubeObjectsFromTemplates(templatesMap, templatesValues)
+	kubeObjects, err := loadManifestObjects(manifestPath)
 	if err != nil {
 		return err
 	}
 
-	kubeObjectKeys := reflect.ValueOf(kubeObjects).MapKeys()
-
-	for _, key := range kubeObjectKeys {
-		kind, name, createErr := kubeobj.CreateObject(k.clientSet, k.crdClientSet, kubeObjects[key.String()], k.namespace)
-
-		if createErr == nil {
-			// skip, everything ok
-		} else if statusError, errIsStatusError := createErr.(*k8sErrors.StatusError); errIsStatusError {
-			if statusError.ErrStatus.Reason == metav1.StatusReasonAlreadyExists {
-				logger.Warning(fmt.Sprintf("%s \"%s\" already exists", kind, name))
-			} else {
-				logger.Error(fmt.Sprintf("%s \"%s\" failed: %v ", kind, name, statusError))
-				return statusError
+	var crds []string
+	summary := map[string]int{}
+	for _, obj := range sortObjects(kubeObjects) {
+		obj := obj
+		objKind, objName, _ := objectMeta(obj, k.namespace)
+		if objKind == "CustomResourceDefinition" {
+			crds = append(crds, objName)
+		} else if len(crds) > 0 {
+			// custom resources and workloads can be created only when definitions are served
+			err = k.waitForCrdsEstablished(crds)
+			if err != nil {
+				return err
 			}
-		} else {
-			logger.Error(fmt.Sprintf("%s \"%s\" failed: %v ", kind, name, createErr))
-			return createErr
+			crds = nil
+		}
+
+		result, applyErr := k.ApplyObject(obj)
+		if applyErr != nil {
+			logger.Error(fmt.Sprintf("%s \"%s\" failed: %v ", objKind, objName, applyErr))
+			return applyErr
 		}
+		if result != ApplyUnchanged {
+			logger.Info(fmt.Sprintf("%s \"%s\" %s", objKind, objName, result))
+		}
+		summary[result]++
 	}
-	return err
+	logger.Info(fmt.Sprintf("Argocd resources: %d created, %d configured, %d unchanged",
+		summary[ApplyCreated], summary[ApplyConfigured], summary[ApplyUnchanged]))
+	return k.waitForCrdsEstablished(crds)
 }
 
 func (k *kube) DeleteObjects(manifestPath string) error {
-	var err error
-	templatesMap, err := buildTemplatesFromManifest(manifestPath)
-	if err != nil {
-		return err
-	}
-	var templatesValues map[string]interface{}
-
-	kubeObjects, _, err := templates.KubeObjectsFromTemplates(templatesMap, templatesValues)
+	kubeObjects, err := loadManifestObjects(manifestPath)
 	if err != nil {
 		return err
 	}
 
 	var kind, name string
 	var deleteError error
-	for _, obj := range kubeObjects {
+	for _, obj := range reverseObjects(sortObjects(kubeObjects)) {
 		kind, name, deleteError = kubeobj.DeleteObject(k.clientSet, k.crdClientSet, obj, k.namespace)
 		if deleteError == nil {
 			fmt.Println(fmt.Sprintf("%s \"%s\" deleted", kind, name))
@@ -312,6 +474,34 @@ func (k *kube) DeleteObjects(manifestPath string) error {
 	return nil
 }
 
+func loadManifestObjects(manifestPath string) (map[string]runtime.Object, error) {
+	templatesMap, err := buildTemplatesFromManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	var templatesValues map[string]interface{}
+
+	kubeObjects, _, err := templates.KubeObjectsFromTemplates(templatesMap, templatesValues)
+	return kubeObjects, err
+}
+
+// objectMeta returns kind, name and namespace of the manifest object
+func objectMeta(obj runtime.Object, defaultNamespace string) (string, string, string) {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if kind == "" {
+		kind = reflect.Indirect(reflect.ValueOf(obj)).Type().Name()
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return kind, "", ""
+	}
+	namespace := accessor.GetNamespace()
+	if namespace == "" && !clusterScopedKinds[kind] {
+		namespace = defaultNamespace
+	}
+	return kind, accessor.GetName(), namespace
+}
+
 func buildTemplatesFromManifest(manifestPath string) (map[string]string, error) {
 	var templatesMap = map[string]string{}
 	var manifestByte []byte
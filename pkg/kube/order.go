@@ -0,0 +1,124 @@
+package kube
+
+import (
+	"errors"
+	"fmt"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const crdEstablishedTimeout = time.Minute
+
+// applyOrder is the order of kinds in which manifest objects are created,
+// objects are deleted in the inverse order. Unknown kinds, e.g. custom resources, go last
+var applyOrder = []string{
+	"Namespace",
+	"CustomResourceDefinition",
+	"ServiceAccount",
+	"ClusterRole",
+	"ClusterRoleBinding",
+	"Role",
+	"RoleBinding",
+	"ConfigMap",
+	"Secret",
+	"Service",
+	"Deployment",
+	"StatefulSet",
+	"DaemonSet",
+	"Job",
+	"NetworkPolicy",
+}
+
+func kindRank(kind string) int {
+	for rank, orderedKind := range applyOrder {
+		if orderedKind == kind {
+			return rank
+		}
+	}
+	return len(applyOrder)
+}
+
+// templateIndex returns position of the object in the manifest from the "template_N.yaml" key
+func templateIndex(key string) int {
+	index, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(key, "template_"), ".yaml"))
+	if err != nil {
+		return -1
+	}
+	return index
+}
+
+// sortObjects returns the manifest objects in apply order, objects of the same kind keep the manifest order
+func sortObjects(kubeObjects map[string]runtime.Object) []runtime.Object {
+	keys := make([]string, 0, len(kubeObjects))
+	for key := range kubeObjects {
+		keys = append(keys, key)
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		kindI, _, _ := objectMeta(kubeObjects[keys[i]], "")
+		kindJ, _, _ := objectMeta(kubeObjects[keys[j]], "")
+		if kindRank(kindI) != kindRank(kindJ) {
+			return kindRank(kindI) < kindRank(kindJ)
+		}
+		return templateIndex(keys[i]) < templateIndex(keys[j])
+	})
+
+	result := make([]runtime.Object, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, kubeObjects[key])
+	}
+	return result
+}
+
+// reverseObjects returns the objects in delete order
+func reverseObjects(objects []runtime.Object) []runtime.Object {
+	result := make([]runtime.Object, 0, len(objects))
+	for i := len(objects) - 1; i >= 0; i-- {
+		result = append(result, objects[i])
+	}
+	return result
+}
+
+// waitForCrdsEstablished polls the CustomResourceDefinitions with the dynamic client in the version
+// preferred by the server, so it works whether v1beta1 is served or not
+func (k *kube) waitForCrdsEstablished(names []string) error {
+	mapping, err := k.mapper.RESTMapping(schema.GroupKind{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"})
+	if err != nil {
+		return err
+	}
+	crds := k.dynamicClient.Resource(mapping.Resource)
+	for _, name := range names {
+		err := wait.PollImmediate(time.Second, crdEstablishedTimeout, func() (bool, error) {
+			crd, err := crds.Get(name, metav1.GetOptions{})
+			if k8sErrors.IsNotFound(err) {
+				return false, nil
+			}
+			if err != nil {
+				return false, err
+			}
+			return isCrdEstablished(crd), nil
+		})
+		if err != nil {
+			return errors.New(fmt.Sprintf("CustomResourceDefinition \"%s\" is not established: %v", name, err))
+		}
+	}
+	return nil
+}
+
+func isCrdEstablished(crd *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(crd.Object, "status", "conditions")
+	for _, condition := range conditions {
+		fields, ok := condition.(map[string]interface{})
+		if ok && fields["type"] == "Established" && fields["status"] == "True" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package kube
+
+import (
+	"fmt"
+	"io/ioutil"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func document(kind string, name string) string {
+	apiVersion := map[string]string{
+		"CustomResourceDefinition": "apiextensions.k8s.io/v1beta1",
+		"ClusterRole":              "rbac.authorization.k8s.io/v1",
+		"ClusterRoleBinding":       "rbac.authorization.k8s.io/v1",
+		"Role":                     "rbac.authorization.k8s.io/v1",
+		"RoleBinding":              "rbac.authorization.k8s.io/v1",
+		"Deployment":               "apps/v1",
+		"StatefulSet":              "apps/v1",
+		"NetworkPolicy":            "networking.k8s.io/v1",
+	}[kind]
+	if apiVersion == "" {
+		apiVersion = "v1"
+	}
+	return fmt.Sprintf("apiVersion: %s\nkind: %s\nmetadata:\n  name: %s\n", apiVersion, kind, name)
+}
+
+// manifestObjects builds the objects the way CreateObjects does, from "template_N.yaml" documents of the manifest
+func manifestObjects(t *testing.T, documents []string) map[string]runtime.Object {
+	dir, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	manifestPath := filepath.Join(dir, "install.yaml")
+	err = ioutil.WriteFile(manifestPath, []byte(strings.Join(documents, "\n---\n")), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	templatesMap, err := buildTemplatesFromManifest(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoder := serializer.NewCodecFactory(applyScheme).UniversalDeserializer()
+	kubeObjects := make(map[string]runtime.Object, len(templatesMap))
+	for key, template := range templatesMap {
+		obj, _, err := decoder.Decode([]byte(template), nil, nil)
+		if err != nil {
+			t.Fatalf("can't decode %s: %v", key, err)
+		}
+		kubeObjects[key] = obj
+	}
+	return kubeObjects
+}
+
+func objectNames(objects []runtime.Object) []string {
+	var names []string
+	for _, obj := range objects {
+		kind, name, _ := objectMeta(obj, "")
+		names = append(names, kind+"/"+name)
+	}
+	return names
+}
+
+func TestSortObjects(t *testing.T) {
+	tests := []struct {
+		name      string
+		documents []string
+		want      []string
+	}{
+		{
+			name: "argocd manifest kinds",
+			documents: []string{
+				document("Deployment", "argocd-server"),
+				document("Service", "argocd-server"),
+				document("ConfigMap", "argocd-cm"),
+				document("RoleBinding", "argocd-server"),
+				document("CustomResourceDefinition", "applications.argoproj.io"),
+				document("Secret", "argocd-secret"),
+				document("ServiceAccount", "argocd-server"),
+				document("StatefulSet", "argocd-application-controller"),
+				document("NetworkPolicy", "argocd-server-network-policy"),
+				document("ClusterRoleBinding", "argocd-server"),
+				document("Role", "argocd-server"),
+				document("ClusterRole", "argocd-server"),
+				document("Namespace", "argocd"),
+			},
+			want: []string{
+				"Namespace/argocd",
+				"CustomResourceDefinition/applications.argoproj.io",
+				"ServiceAccount/argocd-server",
+				"ClusterRole/argocd-server",
+				"ClusterRoleBinding/argocd-server",
+				"Role/argocd-server",
+				"RoleBinding/argocd-server",
+				"ConfigMap/argocd-cm",
+				"Secret/argocd-secret",
+				"Service/argocd-server",
+				"Deployment/argocd-server",
+				"StatefulSet/argocd-application-controller",
+				"NetworkPolicy/argocd-server-network-policy",
+			},
+		},
+		{
+			name: "same kind keeps manifest order past ten documents",
+			documents: []string{
+				document("Service", "s0"),
+				document("ConfigMap", "c1"),
+				document("Service", "s2"),
+				document("ConfigMap", "c3"),
+				document("Service", "s4"),
+				document("ConfigMap", "c5"),
+				document("Service", "s6"),
+				document("ConfigMap", "c7"),
+				document("Service", "s8"),
+				document("ConfigMap", "c9"),
+				document("Service", "s10"),
+				document("ConfigMap", "c11"),
+			},
+			want: []string{
+				"ConfigMap/c1", "ConfigMap/c3", "ConfigMap/c5", "ConfigMap/c7", "ConfigMap/c9", "ConfigMap/c11",
+				"Service/s0", "Service/s2", "Service/s4", "Service/s6", "Service/s8", "Service/s10",
+			},
+		},
+		{
+			name: "unknown kinds go last",
+			documents: []string{
+				document("PodTemplate", "template"),
+				document("Deployment", "argocd-repo-server"),
+				document("Namespace", "argocd"),
+			},
+			want: []string{
+				"Namespace/argocd",
+				"Deployment/argocd-repo-server",
+				"PodTemplate/template",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := sortObjects(manifestObjects(t, tt.documents))
+			got := objectNames(objects)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("apply order\n got: %v\nwant: %v", got, tt.want)
+			}
+
+			deleteOrder := objectNames(reverseObjects(objects))
+			for i := range got {
+				if deleteOrder[i] != got[len(got)-1-i] {
+					t.Fatalf("delete order is not reverse of apply order\n apply: %v\ndelete: %v", got, deleteOrder)
+				}
+			}
+		})
+	}
+}
+
+func TestKindRank(t *testing.T) {
+	tests := []struct {
+		before string
+		after  string
+	}{
+		{"Namespace", "CustomResourceDefinition"},
+		{"CustomResourceDefinition", "ServiceAccount"},
+		{"ServiceAccount", "ClusterRole"},
+		{"RoleBinding", "ConfigMap"},
+		{"Secret", "Service"},
+		{"Service", "Deployment"},
+		{"Deployment", "StatefulSet"},
+		{"NetworkPolicy", "Application"},
+	}
+
+	for _, tt := range tests {
+		if kindRank(tt.before) >= kindRank(tt.after) {
+			t.Errorf("%s should be applied before %s", tt.before, tt.after)
+		}
+	}
+}
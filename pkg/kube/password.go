@@ -0,0 +1,189 @@
+package kube
+
+import (
+	"errors"
+	"fmt"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/logger"
+	"golang.org/x/crypto/bcrypt"
+	core "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+const (
+	// InitialAdminSecretName is the secret with autogenerated admin password since argocd v1.9
+	InitialAdminSecretName = "argocd-initial-admin-secret"
+	// ArgoSecretName is the secret with argocd server settings, including admin password hash
+	ArgoSecretName = "argocd-secret"
+)
+
+var argoVersionRegex = regexp.MustCompile(`:v?(\d+)\.(\d+)`)
+
+// GetAutogeneratedPassword waits for the initial admin password, it's read from the initial admin secret,
+// argocd before v1.9 uses argocd-server pod name instead
+func (k *kube) GetAutogeneratedPassword(timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		password, err := k.getInitialAdminSecretPassword()
+		if err == nil {
+			return password, nil
+		}
+
+		pod, podErr := k.getArgoServerPod()
+		if podErr == nil && usesPodNamePassword(pod) {
+			return pod.Name, nil
+		}
+
+		if time.Now().After(deadline) {
+			if podErr == nil {
+				// unknown argocd version, the pod name is the only password left to try
+				logger.Warning(fmt.Sprintf("Secret \"%s\" not found, using argocd-server pod name as password", InitialAdminSecretName))
+				return pod.Name, nil
+			}
+			return "", errors.New(fmt.Sprintf("Neither secret \"%s\" nor argocd-server pod found: \"%s\"", InitialAdminSecretName, podErr.Error()))
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
+
+// DeleteInitialAdminSecret removes the initial admin secret once the password is changed
+func (k *kube) DeleteInitialAdminSecret() error {
+	secret, err := k.clientSet.CoreV1().Secrets(k.namespace).Get(InitialAdminSecretName, metav1.GetOptions{})
+	if k8sErrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	err = k.clientSet.CoreV1().Secrets(k.namespace).Delete(InitialAdminSecretName, &metav1.DeleteOptions{})
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return err
+	}
+	namespace := k.namespace
+	k.journal.Record(fmt.Sprintf("restore secret \"%s\"", InitialAdminSecretName), func() error {
+		restored := &core.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: secret.Name, Namespace: namespace, Labels: secret.Labels},
+			Type:       secret.Type,
+			Data:       secret.Data,
+		}
+		_, err := k.clientSet.CoreV1().Secrets(namespace).Create(restored)
+		return err
+	})
+	return nil
+}
+
+func (k *kube) getInitialAdminSecretPassword() (string, error) {
+	secret, err := k.clientSet.CoreV1().Secrets(k.namespace).Get(InitialAdminSecretName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	password := string(secret.Data["password"])
+	if password == "" {
+		return "", errors.New(fmt.Sprintf("Secret \"%s\" has no password", InitialAdminSecretName))
+	}
+	return password, nil
+}
+
+func (k *kube) getArgoServerPod() (*core.Pod, error) {
+	opts := metav1.ListOptions{LabelSelector: "app.kubernetes.io/name=argocd-server"}
+	podsList, err := k.clientSet.CoreV1().Pods(k.namespace).List(opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(podsList.Items) == 0 {
+		return nil, errors.New(fmt.Sprint("There is no argocd-server pod"))
+	}
+	return &podsList.Items[0], nil
+}
+
+// usesPodNamePassword reports whether argocd-server image is older than v1.9
+func usesPodNamePassword(pod *core.Pod) bool {
+	for _, container := range pod.Spec.Containers {
+		match := argoVersionRegex.FindStringSubmatch(container.Image)
+		if match == nil {
+			continue
+		}
+		major, _ := strconv.Atoi(match[1])
+		minor, _ := strconv.Atoi(match[2])
+		return major < 1 || (major == 1 && minor < 9)
+	}
+	return false
+}
+
+// SetAdminPassword writes bcrypt hash of the admin password into argocd-secret, argocd-server picks it up
+// on start or when the secret changes, so the current password is not required
+func (k *kube) SetAdminPassword(password string) error {
+	if password == "" {
+		return errors.New(fmt.Sprint("Admin password can't be empty"))
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	passwordData := map[string][]byte{
+		"admin.password":      hash,
+		"admin.passwordMtime": []byte(time.Now().UTC().Format(time.RFC3339)),
+	}
+
+	namespace := k.namespace
+	secrets := k.clientSet.CoreV1().Secrets(namespace)
+	secret, err := secrets.Get(ArgoSecretName, metav1.GetOptions{})
+	if k8sErrors.IsNotFound(err) {
+		secret = &core.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ArgoSecretName,
+				Namespace: namespace,
+				Labels: map[string]string{
+					"app.kubernetes.io/name":    ArgoSecretName,
+					"app.kubernetes.io/part-of": "argocd",
+				},
+			},
+			Type: core.SecretTypeOpaque,
+			Data: passwordData,
+		}
+		_, err = secrets.Create(secret)
+		if err != nil {
+			return err
+		}
+		k.journal.Record(fmt.Sprintf("delete secret \"%s\"", ArgoSecretName), func() error {
+			return secrets.Delete(ArgoSecretName, &metav1.DeleteOptions{})
+		})
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	previous := map[string][]byte{}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	for key, value := range passwordData {
+		if previousValue, ok := secret.Data[key]; ok {
+			previous[key] = previousValue
+		}
+		secret.Data[key] = value
+	}
+	_, err = secrets.Update(secret)
+	if err != nil {
+		return err
+	}
+	k.journal.Record(fmt.Sprintf("restore admin password in secret \"%s\"", ArgoSecretName), func() error {
+		current, err := secrets.Get(ArgoSecretName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		for key := range passwordData {
+			delete(current.Data, key)
+		}
+		for key, value := range previous {
+			current.Data[key] = value
+		}
+		_, err = secrets.Update(current)
+		return err
+	})
+	return nil
+}
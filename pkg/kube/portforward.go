@@ -0,0 +1,113 @@
+package kube
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+	"net/http"
+	"time"
+)
+
+// argoServerContainerPort is the port argocd-server listens on when service target port can't be resolved
+const argoServerContainerPort = 8080
+
+// ArgoServerServiceHost returns in-cluster address of argocd-server service
+func ArgoServerServiceHost(namespace string) string {
+	return fmt.Sprintf("https://argocd-server.%s.svc", namespace)
+}
+
+// PortForwardArgoServer forwards local port to argocd-server pod, random port is used when localPort is 0,
+// the returned function closes the port-forward
+func (k *kube) PortForwardArgoServer(localPort int) (string, func(), error) {
+	pod, err := k.getRunningArgoServerPod()
+	if err != nil {
+		return "", nil, err
+	}
+	remotePort := k.getArgoServerTargetPort(pod)
+
+	transport, upgrader, err := spdy.RoundTripperFor(k.restConfig)
+	if err != nil {
+		return "", nil, err
+	}
+	portForwardUrl := k.clientSet.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.Namespace).
+		Name(pod.Name).
+		SubResource("portforward").
+		URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, portForwardUrl)
+
+	stopChan := make(chan struct{})
+	readyChan := make(chan struct{})
+	ports := []string{fmt.Sprintf("%d:%d", localPort, remotePort)}
+	forwarder, err := portforward.New(dialer, ports, stopChan, readyChan, ioutil.Discard, ioutil.Discard)
+	if err != nil {
+		return "", nil, err
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- forwarder.ForwardPorts()
+	}()
+
+	select {
+	case <-readyChan:
+	case err = <-errChan:
+		return "", nil, errors.New(fmt.Sprintf("Can't forward port to \"%s\": \"%s\"", pod.Name, err.Error()))
+	case <-time.After(30 * time.Second):
+		close(stopChan)
+		return "", nil, errors.New(fmt.Sprintf("Timed out forwarding port to \"%s\"", pod.Name))
+	}
+
+	forwardedPorts, err := forwarder.GetPorts()
+	if err != nil || len(forwardedPorts) == 0 {
+		close(stopChan)
+		return "", nil, errors.New(fmt.Sprintf("Can't get forwarded port of \"%s\"", pod.Name))
+	}
+
+	stop := func() {
+		close(stopChan)
+	}
+	return fmt.Sprintf("https://localhost:%d", forwardedPorts[0].Local), stop, nil
+}
+
+func (k *kube) getRunningArgoServerPod() (*core.Pod, error) {
+	opts := metav1.ListOptions{LabelSelector: "app.kubernetes.io/name=argocd-server"}
+	podsList, err := k.clientSet.CoreV1().Pods(k.namespace).List(opts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range podsList.Items {
+		if podsList.Items[i].Status.Phase == core.PodRunning {
+			return &podsList.Items[i], nil
+		}
+	}
+	return nil, errors.New(fmt.Sprint("There is no running argocd-server pod"))
+}
+
+func (k *kube) getArgoServerTargetPort(pod *core.Pod) int {
+	svc, err := k.GetArgoServerSvc(k.namespace)
+	if err != nil {
+		return argoServerContainerPort
+	}
+	for _, port := range svc.Spec.Ports {
+		if port.Name != "https" && port.Port != 443 {
+			continue
+		}
+		if port.TargetPort.IntVal != 0 {
+			return int(port.TargetPort.IntVal)
+		}
+		for _, container := range pod.Spec.Containers {
+			for _, containerPort := range container.Ports {
+				if containerPort.Name == port.TargetPort.StrVal {
+					return int(containerPort.ContainerPort)
+				}
+			}
+		}
+	}
+	return argoServerContainerPort
+}
@@ -0,0 +1,197 @@
+package kube
+
+import (
+	"fmt"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
+	core "k8s.io/api/core/v1"
+	apixv1beta1client "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/typed/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"time"
+)
+
+type recorder struct {
+	kube      Kube
+	namespace string
+	plan      *install.Plan
+}
+
+// NewRecorder returns Kube which reads from the cluster but only records
+// the mutating calls into the plan
+func NewRecorder(kubeClient Kube, namespace string, plan *install.Plan) Kube {
+	return &recorder{
+		kube:      kubeClient,
+		namespace: namespace,
+		plan:      plan,
+	}
+}
+
+func (r *recorder) GetNamespaces() ([]string, error) {
+	return r.kube.GetNamespaces()
+}
+
+func (r *recorder) GetClientSet() *kubernetes.Clientset {
+	return r.kube.GetClientSet()
+}
+
+func (r *recorder) GetCrdClientSet() *apixv1beta1client.ApiextensionsV1beta1Client {
+	return r.kube.GetCrdClientSet()
+}
+
+func (r *recorder) GetArgoServerSvc(namespace string) (core.Service, error) {
+	svc, err := r.kube.GetArgoServerSvc(namespace)
+	if err != nil {
+		// service is not created yet, it comes from the manifest
+		return core.Service{ObjectMeta: metav1.ObjectMeta{Name: "argocd-server", Namespace: namespace}}, nil
+	}
+	return svc, nil
+}
+
+func (r *recorder) GetLoadBalancerHost(svc core.Service) (string, error) {
+	host, err := r.kube.GetLoadBalancerHost(svc)
+	if err != nil {
+		return "https://<pending load balancer>", nil
+	}
+	return host, nil
+}
+
+func (r *recorder) UseNamespace(namespace string) {
+	r.namespace = namespace
+	r.kube.UseNamespace(namespace)
+}
+
+func (r *recorder) CreateNamespace(namespace string) error {
+	r.namespace = namespace
+	namespaces, _ := r.kube.GetNamespaces()
+	for _, existing := range namespaces {
+		if existing == namespace {
+			return nil
+		}
+	}
+	r.plan.Record(install.PlannedAction{Action: "create", Kind: "Namespace", Name: namespace})
+	return nil
+}
+
+func (r *recorder) GetService(labelSelector string) (*core.Service, error) {
+	svc, err := r.kube.GetService(labelSelector)
+	if err != nil {
+		return &core.Service{ObjectMeta: metav1.ObjectMeta{Name: "argocd-server", Namespace: r.namespace}}, nil
+	}
+	return svc, nil
+}
+
+func (r *recorder) UpdateService(svc *core.Service) error {
+	r.plan.Record(install.PlannedAction{
+		Action:    "update",
+		Kind:      "Service",
+		Name:      svc.Name,
+		Namespace: r.namespace,
+		Details:   "type: " + string(svc.Spec.Type),
+	})
+	return nil
+}
+
+func (r *recorder) GetAutogeneratedPassword(timeout time.Duration) (string, error) {
+	return "", nil
+}
+
+func (r *recorder) DeleteInitialAdminSecret() error {
+	r.plan.Record(install.PlannedAction{Action: "delete", Kind: "Secret", Name: InitialAdminSecretName, Namespace: r.namespace})
+	return nil
+}
+
+func (r *recorder) SetAdminPassword(password string) error {
+	r.plan.Record(install.PlannedAction{
+		Action:    "update",
+		Kind:      "Secret",
+		Name:      ArgoSecretName,
+		Namespace: r.namespace,
+		Details:   "admin.password, admin.passwordMtime",
+	})
+	return nil
+}
+
+func (r *recorder) CreateObjects(manifestPath string) error {
+	return r.recordManifest("apply", manifestPath)
+}
+
+func (r *recorder) DeleteObjects(manifestPath string) error {
+	return r.recordManifest("delete", manifestPath)
+}
+
+func (r *recorder) GetArgoServerHost(expose string) (string, error) {
+	host, err := r.kube.GetArgoServerHost(expose)
+	if err != nil {
+		return fmt.Sprintf("https://<pending %s>", expose), nil
+	}
+	return host, nil
+}
+
+func (r *recorder) IngressApiVersion() (string, error) {
+	return r.kube.IngressApiVersion()
+}
+
+func (r *recorder) PortForwardArgoServer(localPort int) (string, func(), error) {
+	if localPort == 0 {
+		return "https://localhost:<port-forward>", func() {}, nil
+	}
+	return fmt.Sprintf("https://localhost:%d", localPort), func() {}, nil
+}
+
+func (r *recorder) GetConfigMapData(name string) (map[string]string, error) {
+	data, err := r.kube.GetConfigMapData(name)
+	if err != nil {
+		// config map is not created yet, it comes from the manifest
+		return map[string]string{}, nil
+	}
+	return data, nil
+}
+
+func (r *recorder) SaveConfigMapData(name string, data map[string]string) error {
+	r.plan.Record(install.PlannedAction{Action: "update", Kind: "ConfigMap", Name: name, Namespace: r.namespace})
+	return nil
+}
+
+func (r *recorder) GetSecretData(name string) (map[string]string, error) {
+	return r.kube.GetSecretData(name)
+}
+
+func (r *recorder) SaveSecretData(name string, data map[string]string) error {
+	r.plan.Record(install.PlannedAction{Action: "update", Kind: "Secret", Name: name, Namespace: r.namespace})
+	return nil
+}
+
+func (r *recorder) LabelClusterSecret(server string, labels map[string]string) error {
+	r.plan.Record(install.PlannedAction{Action: "label", Kind: "Secret", Name: "cluster " + server, Namespace: r.namespace})
+	return nil
+}
+
+func (r *recorder) ApplyObject(obj runtime.Object) (string, error) {
+	kind, name, namespace := objectMeta(obj, r.namespace)
+	if clusterScopedKinds[kind] {
+		namespace = ""
+	}
+	r.plan.Record(install.PlannedAction{Action: "apply", Kind: kind, Name: name, Namespace: namespace})
+	return ApplyConfigured, nil
+}
+
+func (r *recorder) WaitForWorkloads(manifestPath string, timeout time.Duration) error {
+	return nil
+}
+
+func (r *recorder) recordManifest(action string, manifestPath string) error {
+	kubeObjects, err := loadManifestObjects(manifestPath)
+	if err != nil {
+		return err
+	}
+	objects := sortObjects(kubeObjects)
+	if action == "delete" {
+		objects = reverseObjects(objects)
+	}
+	for _, obj := range objects {
+		kind, name, namespace := objectMeta(obj, r.namespace)
+		r.plan.Record(install.PlannedAction{Action: action, Kind: kind, Name: name, Namespace: namespace})
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package kube
+
+import (
+	"errors"
+	"fmt"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/logger"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"strings"
+	"time"
+)
+
+const workloadsPollInterval = 2 * time.Second
+
+type workload struct {
+	kind string
+	name string
+}
+
+// WaitForWorkloads waits until rollouts of the manifest Deployments and StatefulSets are complete,
+// on timeout the error explains which pods are failing and why
+func (k *kube) WaitForWorkloads(manifestPath string, timeout time.Duration) error {
+	kubeObjects, err := loadManifestObjects(manifestPath)
+	if err != nil {
+		return err
+	}
+	var workloads []workload
+	for _, obj := range sortObjects(kubeObjects) {
+		kind, name, _ := objectMeta(obj, k.namespace)
+		if kind == "Deployment" || kind == "StatefulSet" {
+			workloads = append(workloads, workload{kind: kind, name: name})
+		}
+	}
+	if len(workloads) == 0 {
+		return nil
+	}
+
+	lastReady := -1
+	err = wait.PollImmediate(workloadsPollInterval, timeout, func() (bool, error) {
+		ready := 0
+		for _, w := range workloads {
+			if k.isRolledOut(w) {
+				ready++
+			}
+		}
+		if ready != lastReady {
+			logger.Info(fmt.Sprintf("Argocd workloads ready: %d/%d", ready, len(workloads)))
+			lastReady = ready
+		}
+		return ready == len(workloads), nil
+	})
+	if err == nil {
+		return nil
+	}
+
+	var reasons []string
+	for _, w := range workloads {
+		if !k.isRolledOut(w) {
+			reasons = append(reasons, fmt.Sprintf("%s \"%s\" is not ready%s", w.kind, w.name, k.describeFailingPods(w)))
+		}
+	}
+	return errors.New(fmt.Sprintf("Timed out after %s waiting for argocd workloads: %s", timeout, strings.Join(reasons, "; ")))
+}
+
+func (k *kube) isRolledOut(w workload) bool {
+	switch w.kind {
+	case "Deployment":
+		deployment, err := k.clientSet.AppsV1().Deployments(k.namespace).Get(w.name, metav1.GetOptions{})
+		if err != nil {
+			return false
+		}
+		return isDeploymentRolledOut(deployment)
+	case "StatefulSet":
+		statefulSet, err := k.clientSet.AppsV1().StatefulSets(k.namespace).Get(w.name, metav1.GetOptions{})
+		if err != nil {
+			return false
+		}
+		return isStatefulSetRolledOut(statefulSet)
+	}
+	return true
+}
+
+func desiredReplicas(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
+
+func isDeploymentRolledOut(deployment *apps.Deployment) bool {
+	replicas := desiredReplicas(deployment.Spec.Replicas)
+	status := deployment.Status
+	return status.ObservedGeneration >= deployment.Generation &&
+		status.UpdatedReplicas == replicas &&
+		status.Replicas == replicas &&
+		status.AvailableReplicas == replicas
+}
+
+func isStatefulSetRolledOut(statefulSet *apps.StatefulSet) bool {
+	replicas := desiredReplicas(statefulSet.Spec.Replicas)
+	status := statefulSet.Status
+	return status.ObservedGeneration >= statefulSet.Generation &&
+		status.UpdatedReplicas == replicas &&
+		status.ReadyReplicas == replicas
+}
+
+// describeFailingPods returns reasons of the workload pods which are not running,
+// e.g. ImagePullBackOff or CrashLoopBackOff
+func (k *kube) describeFailingPods(w workload) string {
+	var selector *metav1.LabelSelector
+	switch w.kind {
+	case "Deployment":
+		deployment, err := k.clientSet.AppsV1().Deployments(k.namespace).Get(w.name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Sprintf(" (%v)", err)
+		}
+		selector = deployment.Spec.Selector
+	case "StatefulSet":
+		statefulSet, err := k.clientSet.AppsV1().StatefulSets(k.namespace).Get(w.name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Sprintf(" (%v)", err)
+		}
+		selector = statefulSet.Spec.Selector
+	}
+
+	labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return ""
+	}
+	pods, err := k.clientSet.CoreV1().Pods(k.namespace).List(metav1.ListOptions{LabelSelector: labelSelector.String()})
+	if err != nil {
+		return ""
+	}
+	if len(pods.Items) == 0 {
+		return " (no pods created)"
+	}
+
+	var reasons []string
+	for _, pod := range pods.Items {
+		reasons = append(reasons, podFailureReasons(pod)...)
+	}
+	if len(reasons) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", strings.Join(reasons, ", "))
+}
+
+func podFailureReasons(pod core.Pod) []string {
+	var reasons []string
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == core.PodScheduled && condition.Status == core.ConditionFalse {
+			reasons = append(reasons, fmt.Sprintf("pod \"%s\" %s: %s", pod.Name, condition.Reason, condition.Message))
+		}
+	}
+	statuses := append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if status.State.Waiting != nil && status.State.Waiting.Reason != "ContainerCreating" && status.State.Waiting.Reason != "PodInitializing" {
+			reason := fmt.Sprintf("pod \"%s\" container \"%s\" %s", pod.Name, status.Name, status.State.Waiting.Reason)
+			if status.State.Waiting.Message != "" {
+				reason += ": " + status.State.Waiting.Message
+			}
+			if status.LastTerminationState.Terminated != nil {
+				reason += fmt.Sprintf(", last exit code %d", status.LastTerminationState.Terminated.ExitCode)
+			}
+			reasons = append(reasons, reason)
+		} else if status.State.Running != nil && !status.Ready {
+			reasons = append(reasons, fmt.Sprintf("pod \"%s\" container \"%s\" is running but not ready", pod.Name, status.Name))
+		}
+	}
+	return reasons
+}
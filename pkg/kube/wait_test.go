@@ -0,0 +1,131 @@
+package kube
+
+import (
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	"testing"
+)
+
+func TestIsDeploymentRolledOut(t *testing.T) {
+	two := int32(2)
+	tests := []struct {
+		name       string
+		generation int64
+		replicas   *int32
+		status     apps.DeploymentStatus
+		want       bool
+	}{
+		{
+			name:       "rolled out",
+			generation: 2,
+			replicas:   &two,
+			status:     apps.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+			want:       true,
+		},
+		{
+			name:       "one replica by default",
+			generation: 1,
+			status:     apps.DeploymentStatus{ObservedGeneration: 1, Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1},
+			want:       true,
+		},
+		{
+			name:       "controller has not observed the new generation",
+			generation: 3,
+			replicas:   &two,
+			status:     apps.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+		},
+		{
+			name:       "unavailable replica",
+			generation: 2,
+			replicas:   &two,
+			status:     apps.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 1, UnavailableReplicas: 1},
+		},
+		{
+			name:       "old replica is not replaced yet",
+			generation: 2,
+			replicas:   &two,
+			status:     apps.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 2, AvailableReplicas: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployment := &apps.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "argocd-server", Generation: tt.generation},
+				Spec:       apps.DeploymentSpec{Replicas: tt.replicas},
+				Status:     tt.status,
+			}
+			if got := isDeploymentRolledOut(deployment); got != tt.want {
+				t.Errorf("isDeploymentRolledOut() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPodFailureReasons(t *testing.T) {
+	tests := []struct {
+		name   string
+		status core.PodStatus
+		want   []string
+	}{
+		{
+			name: "crash loop with last exit code",
+			status: core.PodStatus{ContainerStatuses: []core.ContainerStatus{{
+				Name:                 "argocd-server",
+				State:                core.ContainerState{Waiting: &core.ContainerStateWaiting{Reason: "CrashLoopBackOff", Message: "back-off 5m0s restarting failed container"}},
+				LastTerminationState: core.ContainerState{Terminated: &core.ContainerStateTerminated{ExitCode: 1}},
+			}}},
+			want: []string{"pod \"argocd-server-1\" container \"argocd-server\" CrashLoopBackOff: back-off 5m0s restarting failed container, last exit code 1"},
+		},
+		{
+			name: "image pull back off",
+			status: core.PodStatus{ContainerStatuses: []core.ContainerStatus{{
+				Name:  "argocd-server",
+				State: core.ContainerState{Waiting: &core.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "Back-off pulling image \"argoproj/argocd:v0\""}},
+			}}},
+			want: []string{"pod \"argocd-server-1\" container \"argocd-server\" ImagePullBackOff: Back-off pulling image \"argoproj/argocd:v0\""},
+		},
+		{
+			name: "init container failure",
+			status: core.PodStatus{InitContainerStatuses: []core.ContainerStatus{{
+				Name:  "copyutil",
+				State: core.ContainerState{Waiting: &core.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			}}},
+			want: []string{"pod \"argocd-server-1\" container \"copyutil\" CrashLoopBackOff"},
+		},
+		{
+			name: "unschedulable",
+			status: core.PodStatus{Conditions: []core.PodCondition{{
+				Type: core.PodScheduled, Status: core.ConditionFalse, Reason: "Unschedulable", Message: "0/1 nodes are available",
+			}}},
+			want: []string{"pod \"argocd-server-1\" Unschedulable: 0/1 nodes are available"},
+		},
+		{
+			name: "running but not ready",
+			status: core.PodStatus{ContainerStatuses: []core.ContainerStatus{{
+				Name:  "argocd-server",
+				State: core.ContainerState{Running: &core.ContainerStateRunning{}},
+			}}},
+			want: []string{"pod \"argocd-server-1\" container \"argocd-server\" is running but not ready"},
+		},
+		{
+			name: "starting containers are not failures",
+			status: core.PodStatus{ContainerStatuses: []core.ContainerStatus{
+				{Name: "argocd-server", State: core.ContainerState{Waiting: &core.ContainerStateWaiting{Reason: "ContainerCreating"}}},
+				{Name: "dex", State: core.ContainerState{Waiting: &core.ContainerStateWaiting{Reason: "PodInitializing"}}},
+				{Name: "redis", State: core.ContainerState{Running: &core.ContainerStateRunning{}}, Ready: true},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := core.Pod{ObjectMeta: metav1.ObjectMeta{Name: "argocd-server-1"}, Status: tt.status}
+			if got := podFailureReasons(pod); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("podFailureReasons() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package logger
+
+import (
+	"fmt"
+	listenerLogger "github.com/codefresh-io/argocd-listener/installer/pkg/logger"
+	"io"
+)
+
+// output receives the messages instead of the listener logger when it's set
+var output io.Writer
+
+// SetOutput writes the messages to out, e.g. dry run writes them to stderr so stdout has the plan only,
+// nil restores the listener logger
+func SetOutput(out io.Writer) {
+	output = out
+}
+
+func Success(message string) {
+	write(message, listenerLogger.Success)
+}
+
+func Info(message string) {
+	write(message, listenerLogger.Info)
+}
+
+func Warning(message string) {
+	write(message, listenerLogger.Warning)
+}
+
+func Error(message string) {
+	write(message, listenerLogger.Error)
+}
+
+func write(message string, log func(string)) {
+	if output == nil {
+		log(message)
+		return
+	}
+	_, _ = fmt.Fprintln(output, message)
+}
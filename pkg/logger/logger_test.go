@@ -0,0 +1,22 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSetOutput(t *testing.T) {
+	var out bytes.Buffer
+	SetOutput(&out)
+	defer SetOutput(nil)
+
+	Success("installed")
+	Info("creating")
+	Warning("retrying")
+	Error("failed")
+
+	want := "installed\ncreating\nretrying\nfailed\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,64 @@
+package questionnaire
+
+import (
+	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
+	"os"
+)
+
+// IsInteractive reports whether questions can be asked, i.e. stdin is a terminal
+func IsInteractive() bool {
+	stat, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+// MissingAnswers lists the answers which are not provided by flags or answers file
+// and would require a prompt during the installation
+func MissingAnswers(installOptions *install.CmdOptions) []string {
+	var missing []string
+	yes := installOptions.Questionnaire.Yes
+
+	if installOptions.Argo.Password == "" && !installOptions.Argo.GeneratePassword {
+		missing = append(missing, "argo.password (--argo-password or --generate-password)")
+	}
+	if installOptions.Controller.Expose == install.ExposeIngress && installOptions.Controller.Ingress.Host == "" {
+		missing = append(missing, "controller.ingress.host (--ingress-host)")
+	}
+	if installOptions.Controller.Expose == install.ExposeNone && installOptions.Argo.Host == "" {
+		missing = append(missing, "argo.host (--argo-host)")
+	}
+	if yes {
+		return missing
+	}
+
+	if installOptions.Controller.Expose == "" && !installOptions.Controller.LoadBalancer {
+		missing = append(missing, "controller.expose (--expose)")
+	}
+	if installOptions.Kube.Context == "" {
+		missing = append(missing, "kube.context (--kube-context-name)")
+	}
+	if installOptions.Kube.Namespace == "" {
+		missing = append(missing, "kube.namespace (--kube-namespace)")
+	}
+
+	if installOptions.Codefresh.ImportClusters == nil {
+		missing = append(missing, "codefresh.importClusters (--import-clusters)")
+	} else if *installOptions.Codefresh.ImportClusters && len(installOptions.Codefresh.Clusters) == 0 {
+		missing = append(missing, "codefresh.clusters (--codefresh-clusters)")
+	}
+
+	addRepo := installOptions.Git.AddRepo != nil && *installOptions.Git.AddRepo
+	if installOptions.Git.AddRepo == nil {
+		missing = append(missing, "git.addRepo (--add-repo)")
+	} else if addRepo && installOptions.Git.RepoUrl == "" {
+		missing = append(missing, "git.repoUrl (--git-repo-url)")
+	}
+	// credentials template takes the credentials of git integration even without the manifest repo
+	if (addRepo || installOptions.Git.CredsTemplateUrl != "") && installOptions.Git.Integration == "" && installOptions.Git.Auth.GithubApp.AppID == 0 {
+		missing = append(missing, "git.integration (--git-integration)")
+	}
+
+	return missing
+}
@@ -0,0 +1,74 @@
+package questionnaire
+
+import (
+	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
+	"reflect"
+	"testing"
+)
+
+func TestMissingAnswers(t *testing.T) {
+	complete := func() *install.CmdOptions {
+		options := &install.CmdOptions{}
+		options.Argo.GeneratePassword = true
+		options.Controller.Expose = install.ExposePortForward
+		options.Kube.Context = "minikube"
+		options.Kube.Namespace = "argocd"
+		options.Codefresh.ImportClusters = boolAnswer(false)
+		options.Git.AddRepo = boolAnswer(false)
+		return options
+	}
+
+	tests := []struct {
+		name   string
+		modify func(options *install.CmdOptions)
+		want   []string
+	}{
+		{
+			name:   "all answered",
+			modify: func(options *install.CmdOptions) {},
+		},
+		{
+			name: "yes needs only the answers without defaults",
+			modify: func(options *install.CmdOptions) {
+				options.Questionnaire.Yes = true
+				options.Kube.Context = ""
+				options.Codefresh.ImportClusters = nil
+				options.Git.AddRepo = nil
+			},
+		},
+		{
+			name: "confirmations name their flags",
+			modify: func(options *install.CmdOptions) {
+				options.Codefresh.ImportClusters = nil
+				options.Git.AddRepo = nil
+			},
+			want: []string{"codefresh.importClusters (--import-clusters)", "git.addRepo (--add-repo)"},
+		},
+		{
+			name: "added repo needs url and integration",
+			modify: func(options *install.CmdOptions) {
+				options.Git.AddRepo = boolAnswer(true)
+			},
+			want: []string{"git.repoUrl (--git-repo-url)", "git.integration (--git-integration)"},
+		},
+		{
+			name: "imported clusters need selection",
+			modify: func(options *install.CmdOptions) {
+				options.Codefresh.ImportClusters = boolAnswer(true)
+			},
+			want: []string{"codefresh.clusters (--codefresh-clusters)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := complete()
+			tt.modify(options)
+
+			got := MissingAnswers(options)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MissingAnswers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,17 +1,49 @@
 package questionnaire
 
 import (
-	"github.com/codefresh-io/argocd-listener/installer/pkg/prompt"
+	"errors"
+	"fmt"
 	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/logger"
 )
 
-func AskAboutPass(installOptions *install.CmdOptions) error {
-	installOptions.Argo.Password = askAboutPass()
-	return nil
-}
+const passwordAttempts = 3
+
+func AskAboutPass(prompter Prompter, installOptions *install.CmdOptions) error {
+	policy := installOptions.Argo.PasswordPolicy
+	if installOptions.Argo.Password != "" {
+		return ValidatePassword(installOptions.Argo.Password, policy)
+	}
+	if installOptions.Argo.GeneratePassword {
+		password, err := GeneratePassword(policy)
+		if err != nil {
+			return err
+		}
+		installOptions.Argo.Password = password
+		return nil
+	}
 
-func askAboutPass() string {
-	var firstPassword string
-	_ = prompt.NewPrompt().InputPassword(&firstPassword, "Please specify root password for ArgoCD")
-	return firstPassword
+	for attempt := 0; attempt < passwordAttempts; attempt++ {
+		var password, confirmation string
+		err := prompter.InputPassword(&password, "Please specify root password for ArgoCD")
+		if err != nil {
+			return err
+		}
+		err = ValidatePassword(password, policy)
+		if err != nil {
+			logger.Warning(err.Error())
+			continue
+		}
+		err = prompter.InputPassword(&confirmation, "Please confirm root password for ArgoCD")
+		if err != nil {
+			return err
+		}
+		if password != confirmation {
+			logger.Warning(fmt.Sprint("Passwords don't match"))
+			continue
+		}
+		installOptions.Argo.Password = password
+		return nil
+	}
+	return errors.New(fmt.Sprintf("No valid password after %d attempts", passwordAttempts))
 }
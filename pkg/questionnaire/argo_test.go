@@ -0,0 +1,102 @@
+package questionnaire
+
+import (
+	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
+	"testing"
+)
+
+func TestAskAboutPass(t *testing.T) {
+	tests := []struct {
+		name             string
+		prompter         prompterCase
+		password         string
+		generate         bool
+		policy           install.PasswordPolicy
+		want             string
+		wantAsked        int
+		wantErr          string
+		wantGeneratedLen int
+	}{
+		{
+			name:     "password of flags is not asked",
+			prompter: scripted(),
+			password: "Secret123",
+			want:     "Secret123",
+		},
+		{
+			name:     "password of flags violates policy",
+			prompter: scripted(),
+			password: "short",
+			wantErr:  "at least 8 characters",
+		},
+		{
+			name:             "generated password",
+			prompter:         scripted(),
+			generate:         true,
+			policy:           install.PasswordPolicy{MinLength: 8, RequireComplexity: true},
+			wantGeneratedLen: generatedPasswordLength,
+		},
+		{
+			name:      "password with confirmation",
+			prompter:  scripted("Secret123", "Secret123"),
+			want:      "Secret123",
+			wantAsked: 2,
+		},
+		{
+			name:      "confirmation mismatch is asked again",
+			prompter:  scripted("Secret123", "Secret124", "Secret123", "Secret123"),
+			want:      "Secret123",
+			wantAsked: 4,
+		},
+		{
+			name:      "empty password is asked again",
+			prompter:  scripted("", "Secret123", "Secret123"),
+			want:      "Secret123",
+			wantAsked: 3,
+		},
+		{
+			name:      "complexity is required",
+			prompter:  scripted("secret123", "Secret123", "Secret123"),
+			policy:    install.PasswordPolicy{MinLength: 8, RequireComplexity: true},
+			want:      "Secret123",
+			wantAsked: 3,
+		},
+		{
+			name:      "no valid password after attempts",
+			prompter:  scripted("a", "b", "c"),
+			wantAsked: 3,
+			wantErr:   "No valid password after 3 attempts",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &install.CmdOptions{}
+			options.Argo.Password = tt.password
+			options.Argo.GeneratePassword = tt.generate
+			options.Argo.PasswordPolicy = tt.policy
+			if options.Argo.PasswordPolicy.MinLength == 0 {
+				options.Argo.PasswordPolicy.MinLength = 8
+			}
+			prompter := tt.prompter()
+
+			err := AskAboutPass(prompter, options)
+			assertAsked(t, prompter, tt.wantAsked)
+			if assertError(t, err, tt.wantErr) {
+				return
+			}
+			if tt.wantGeneratedLen > 0 {
+				if len(options.Argo.Password) != tt.wantGeneratedLen {
+					t.Errorf("generated password length = %d, want %d", len(options.Argo.Password), tt.wantGeneratedLen)
+				}
+				if err := ValidatePassword(options.Argo.Password, tt.policy); err != nil {
+					t.Errorf("generated password violates policy: %v", err)
+				}
+				return
+			}
+			if options.Argo.Password != tt.want {
+				t.Errorf("password = %q, want %q", options.Argo.Password, tt.want)
+			}
+		})
+	}
+}
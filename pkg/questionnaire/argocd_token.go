@@ -3,8 +3,8 @@ package questionnaire
 import (
 	"fmt"
 	"github.com/avast/retry-go"
-	"github.com/codefresh-io/argocd-listener/installer/pkg/logger"
 	argoSdk "github.com/codefresh-io/argocd-sdk/pkg/api"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/logger"
 	"time"
 )
 
@@ -1,13 +1,23 @@
 package questionnaire
 
 import (
-	"github.com/codefresh-io/argocd-listener/installer/pkg/prompt"
 	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
 	"github.com/codefresh-io/go-sdk/pkg/codefresh"
 )
 
-func AskAboutClusters(installOptions *install.CmdOptions, clusters []*codefresh.ClusterMinified) error {
-	if len(clusters) < 1 {
+func AskAboutClustersImport(prompter Prompter, installOptions *install.CmdOptions) (bool, error) {
+	if installOptions.Codefresh.ImportClusters == nil {
+		err, importClusters := prompter.Confirm("Would you like to integrate clusters from your account to ArgoCD?")
+		if err != nil {
+			return false, err
+		}
+		installOptions.Codefresh.ImportClusters = &importClusters
+	}
+	return *installOptions.Codefresh.ImportClusters, nil
+}
+
+func AskAboutClusters(prompter Prompter, installOptions *install.CmdOptions, clusters []*codefresh.ClusterMinified) error {
+	if len(clusters) < 1 || len(installOptions.Codefresh.Clusters) > 0 {
 		return nil
 	}
 
@@ -16,7 +26,10 @@ func AskAboutClusters(installOptions *install.CmdOptions, clusters []*codefresh.
 		clustersSelectors = append(clustersSelectors, cluster.Selector)
 	}
 
-	_, clustersForSync := prompt.NewPrompt().Multiselect(clustersSelectors, "Select clusters your would be like to register")
+	err, clustersForSync := prompter.Multiselect(clustersSelectors, "Select clusters your would be like to register")
+	if err != nil {
+		return err
+	}
 	installOptions.Codefresh.Clusters = clustersForSync
 	return nil
 }
@@ -0,0 +1,98 @@
+package questionnaire
+
+import (
+	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
+	"github.com/codefresh-io/go-sdk/pkg/codefresh"
+	"reflect"
+	"testing"
+)
+
+func TestAskAboutClustersImport(t *testing.T) {
+	tests := []struct {
+		name      string
+		prompter  prompterCase
+		answer    *bool
+		want      bool
+		wantAsked int
+	}{
+		{
+			name:     "declined in answers file is not asked",
+			prompter: scripted(),
+			answer:   boolAnswer(false),
+			want:     false,
+		},
+		{
+			name:      "confirmed answer is kept",
+			prompter:  scripted(true),
+			want:      true,
+			wantAsked: 1,
+		},
+		{
+			name:     "declined without explicit answer",
+			prompter: defaults,
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &install.CmdOptions{}
+			options.Codefresh.ImportClusters = tt.answer
+			prompter := tt.prompter()
+
+			got, err := AskAboutClustersImport(prompter, options)
+			assertAsked(t, prompter, tt.wantAsked)
+			assertError(t, err, "")
+			if got != tt.want || *options.Codefresh.ImportClusters != tt.want {
+				t.Errorf("import = %v, answer %v, want %v", got, *options.Codefresh.ImportClusters, tt.want)
+			}
+		})
+	}
+}
+
+func TestAskAboutClusters(t *testing.T) {
+	clusters := []*codefresh.ClusterMinified{{Selector: "production"}, {Selector: "staging"}}
+
+	tests := []struct {
+		name      string
+		prompter  prompterCase
+		clusters  []*codefresh.ClusterMinified
+		selected  []string
+		want      []string
+		wantAsked int
+	}{
+		{
+			name:     "no clusters in account",
+			prompter: scripted(),
+		},
+		{
+			name:     "clusters of flags are not asked",
+			prompter: scripted(),
+			clusters: clusters,
+			selected: []string{"staging"},
+			want:     []string{"staging"},
+		},
+		{
+			name:      "selected among account clusters",
+			prompter:  scripted([]string{"production"}),
+			clusters:  clusters,
+			want:      []string{"production"},
+			wantAsked: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &install.CmdOptions{}
+			options.Codefresh.Clusters = tt.selected
+			prompter := tt.prompter()
+
+			err := AskAboutClusters(prompter, options, tt.clusters)
+			assertAsked(t, prompter, tt.wantAsked)
+			assertError(t, err, "")
+			if !reflect.DeepEqual(options.Codefresh.Clusters, tt.want) {
+				t.Errorf("clusters = %v, want %v", options.Codefresh.Clusters, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package questionnaire
+
+import (
+	"errors"
+	"fmt"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/kube"
+	core "k8s.io/api/core/v1"
+)
+
+var exposeModes = []string{install.ExposeLoadBalancer, install.ExposeIngress, install.ExposeNodePort, install.ExposePortForward, install.ExposeNone}
+
+func setServiceType(kubeClient kube.Kube, serviceType core.ServiceType) error {
+	argocdServer, err := kubeClient.GetService("app.kubernetes.io/name=argocd-server")
+	if err != nil {
+		return errors.New(fmt.Sprintf("Can't get argocd server: \"%s\"", err.Error()))
+	}
+	argocdServer.Spec.Type = serviceType
+	err = kubeClient.UpdateService(argocdServer)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Can't change service type to %s: \"%s\"", serviceType, err.Error()))
+	}
+	return nil
+}
+
+func initIngress(prompter Prompter, installOptions *install.CmdOptions, kubeClient kube.Kube) error {
+	ingressOptions := &installOptions.Controller.Ingress
+	if ingressOptions.Host == "" {
+		err := prompter.InputWithDefault(&ingressOptions.Host, "Argocd ingress host", "")
+		if err != nil {
+			return err
+		}
+		if ingressOptions.Host == "" {
+			return errors.New("Ingress host is required to expose argocd with ingress")
+		}
+	}
+
+	apiVersion, err := kubeClient.IngressApiVersion()
+	if err != nil {
+		return errors.New(fmt.Sprintf("Can't discover ingress api version: \"%s\"", err.Error()))
+	}
+	ingress := kube.NewArgoServerIngress(apiVersion, installOptions.Kube.Namespace, ingressOptions.Host, ingressOptions.TlsSecret, ingressOptions.Class, ingressOptions.Annotations)
+	_, err = kubeClient.ApplyObject(ingress)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Can't create argocd ingress: \"%s\"", err.Error()))
+	}
+	return nil
+}
+
+// AskAboutExposure resolves the way argocd-server is exposed and applies it to the cluster
+func AskAboutExposure(prompter Prompter, installOptions *install.CmdOptions, kubeClient kube.Kube) error {
+	if installOptions.Controller.Expose == "" && installOptions.Controller.LoadBalancer {
+		installOptions.Controller.Expose = install.ExposeLoadBalancer
+	}
+
+	if installOptions.Controller.Expose == "" {
+		err, expose := prompter.Select(exposeModes, "How would you like to expose ArgoCD? ( An external address is required when using Codefresh steps )")
+		if err != nil {
+			return err
+		}
+		installOptions.Controller.Expose = expose
+	}
+
+	switch installOptions.Controller.Expose {
+	case install.ExposeLoadBalancer:
+		return setServiceType(kubeClient, core.ServiceTypeLoadBalancer)
+	case install.ExposeNodePort:
+		return setServiceType(kubeClient, core.ServiceTypeNodePort)
+	case install.ExposeIngress:
+		return initIngress(prompter, installOptions, kubeClient)
+	case install.ExposePortForward, install.ExposeNone:
+		return nil
+	}
+	return errors.New(fmt.Sprintf("Unknown expose mode \"%s\", use one of: loadbalancer, ingress, nodeport, port-forward, none", installOptions.Controller.Expose))
+}
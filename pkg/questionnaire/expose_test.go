@@ -0,0 +1,134 @@
+package questionnaire
+
+import (
+	"errors"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/kube"
+	core "k8s.io/api/core/v1"
+	"reflect"
+	"testing"
+)
+
+// emptyCluster is a cluster without argocd, the recorder falls back to the objects of the manifest
+type emptyCluster struct {
+	kube.Kube
+}
+
+func (c *emptyCluster) GetService(labelSelector string) (*core.Service, error) {
+	return nil, errors.New("services \"argocd-server\" not found")
+}
+
+func (c *emptyCluster) IngressApiVersion() (string, error) {
+	return kube.IngressV1, nil
+}
+
+func TestAskAboutExposure(t *testing.T) {
+	const (
+		loadBalancer = "update Service argocd/argocd-server (type: LoadBalancer)"
+		nodePort     = "update Service argocd/argocd-server (type: NodePort)"
+		ingress      = "apply Ingress argocd/argocd-server"
+	)
+
+	tests := []struct {
+		name         string
+		prompter     prompterCase
+		loadBalancer bool
+		expose       string
+		ingressHost  string
+		wantExpose   string
+		wantHost     string
+		wantActions  []string
+		wantAsked    int
+		wantErr      string
+	}{
+		{
+			name:         "load balancer flag is not asked",
+			prompter:     scripted(),
+			loadBalancer: true,
+			wantExpose:   install.ExposeLoadBalancer,
+			wantActions:  []string{loadBalancer},
+		},
+		{
+			name:         "expose flag takes precedence over load balancer flag",
+			prompter:     scripted(),
+			loadBalancer: true,
+			expose:       install.ExposeNodePort,
+			wantExpose:   install.ExposeNodePort,
+			wantActions:  []string{nodePort},
+		},
+		{
+			name:        "ingress host of flags is not asked",
+			prompter:    scripted(),
+			expose:      install.ExposeIngress,
+			ingressHost: "argocd.example.com",
+			wantExpose:  install.ExposeIngress,
+			wantHost:    "argocd.example.com",
+			wantActions: []string{ingress},
+		},
+		{
+			name:      "ingress host is required",
+			prompter:  scripted(""),
+			expose:    install.ExposeIngress,
+			wantAsked: 1,
+			wantErr:   "Ingress host is required",
+		},
+		{
+			name:       "port-forward changes nothing",
+			prompter:   scripted(),
+			expose:     install.ExposePortForward,
+			wantExpose: install.ExposePortForward,
+		},
+		{
+			name:       "none changes nothing",
+			prompter:   scripted(),
+			expose:     install.ExposeNone,
+			wantExpose: install.ExposeNone,
+		},
+		{
+			name:        "selected ingress asks host",
+			prompter:    scripted(install.ExposeIngress, "argocd.example.com"),
+			wantExpose:  install.ExposeIngress,
+			wantHost:    "argocd.example.com",
+			wantActions: []string{ingress},
+			wantAsked:   2,
+		},
+		{
+			name:     "unknown mode",
+			prompter: scripted(),
+			expose:   "route",
+			wantErr:  "Unknown expose mode \"route\"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &install.CmdOptions{}
+			options.Kube.Namespace = "argocd"
+			options.Controller.LoadBalancer = tt.loadBalancer
+			options.Controller.Expose = tt.expose
+			options.Controller.Ingress.Host = tt.ingressHost
+			plan := &install.Plan{}
+			kubeClient := kube.NewRecorder(&emptyCluster{}, "argocd", plan)
+			prompter := tt.prompter()
+
+			err := AskAboutExposure(prompter, options, kubeClient)
+			assertAsked(t, prompter, tt.wantAsked)
+			if assertError(t, err, tt.wantErr) {
+				return
+			}
+			if options.Controller.Expose != tt.wantExpose {
+				t.Errorf("expose = %q, want %q", options.Controller.Expose, tt.wantExpose)
+			}
+			if options.Controller.Ingress.Host != tt.wantHost {
+				t.Errorf("ingress host = %q, want %q", options.Controller.Ingress.Host, tt.wantHost)
+			}
+			var actions []string
+			for _, action := range plan.Actions {
+				actions = append(actions, action.String())
+			}
+			if !reflect.DeepEqual(actions, tt.wantActions) {
+				t.Errorf("actions = %v, want %v", actions, tt.wantActions)
+			}
+		})
+	}
+}
@@ -1,45 +1,146 @@
 package questionnaire
 
 import (
+	"errors"
 	"fmt"
-	"github.com/codefresh-io/argocd-listener/installer/pkg/logger"
-	"github.com/codefresh-io/argocd-listener/installer/pkg/prompt"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/git"
 	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/logger"
 	"github.com/codefresh-io/go-sdk/pkg/codefresh"
+	"strings"
 )
 
-func AskAboutGitRepo(installOptions *install.CmdOptions) error {
-	if installOptions.Git.Integration == "" || installOptions.Git.Auth.Pass == "" {
+const defaultManifestRepo = "https://github.com/argoproj/argocd-example-apps"
+
+const repoAttempts = 3
+
+func AskAboutManifestRepo(prompter Prompter, installOptions *install.CmdOptions) (bool, error) {
+	if installOptions.Git.AddRepo == nil {
+		err, addRepo := prompter.Confirm("Would you like to integrate git context for manifest repo from your account to ArgoCD?")
+		if err != nil {
+			return false, err
+		}
+		installOptions.Git.AddRepo = &addRepo
+	}
+	return *installOptions.Git.AddRepo, nil
+}
+
+// AskAboutGitRepo asks for the manifest repo and checks its access, host key of ssh repo is verified against knownHosts
+func AskAboutGitRepo(prompter Prompter, installOptions *install.CmdOptions, knownHosts []string) error {
+	auth := installOptions.Git.Auth
+	if auth.Pass == "" && auth.SshPrivateKey == "" && auth.GithubApp.AppID == 0 {
 		return nil
 	}
-	_ = prompt.NewPrompt().InputWithDefault(&installOptions.Git.RepoUrl, "Please specify url to your manifest repository to add to ArgoCD", "https://github.com/argoproj/argocd-example-apps")
+	defaultRepo := defaultManifestRepo
+	if auth.Pass == "" && auth.GithubApp.AppID == 0 {
+		// ssh only git context
+		defaultRepo, _ = git.ToSshUrl(defaultManifestRepo)
+	}
+	if installOptions.Git.RepoUrl != "" {
+		// url of flags or answers file is not replaced by a prompted one
+		return checkRepo(installOptions, knownHosts)
+	}
+	for attempt := 1; ; attempt++ {
+		err := prompter.InputWithDefault(&installOptions.Git.RepoUrl, "Please specify url to your manifest repository to add to ArgoCD", defaultRepo)
+		if err != nil {
+			return err
+		}
+		err = checkRepo(installOptions, knownHosts)
+		if err == nil || !isInteractivePrompter(prompter) || attempt == repoAttempts {
+			return err
+		}
+		logger.Warning(fmt.Sprintf("Can't access git repo: \"%s\"", err.Error()))
+		installOptions.Git.RepoUrl = ""
+	}
+}
+
+// checkRepo lists the repo branches with the credentials argocd will use
+func checkRepo(installOptions *install.CmdOptions, knownHosts []string) error {
+	repoUrl := installOptions.Git.RepoUrl
+	if installOptions.Git.SkipRepoCheck {
+		return git.ValidateUrl(repoUrl)
+	}
+	branches, err := git.ListBranches(repoUrl, repoCredentials(installOptions), knownHosts)
+	if err != nil {
+		return err
+	}
+	logger.Info(fmt.Sprintf("Repo \"%s\" is accessible, branches: %s", repoUrl, strings.Join(branches, ", ")))
 	return nil
 }
 
-func AskAboutGitContext(installOptions *install.CmdOptions, contexts *[]codefresh.ContextPayload) error {
+// repoCredentials are the credentials argocd will use for the repo
+func repoCredentials(installOptions *install.CmdOptions) git.RepoCredentials {
+	auth := installOptions.Git.Auth
+	credentials := git.RepoCredentials{
+		Username:      auth.Username,
+		Password:      auth.Pass,
+		SshPrivateKey: auth.SshPrivateKey,
+	}
+	if auth.GithubApp.AppID != 0 {
+		credentials.GithubApp = &git.GithubAppCredentials{
+			AppID:             auth.GithubApp.AppID,
+			InstallationID:    auth.GithubApp.InstallationID,
+			PrivateKey:        auth.GithubApp.PrivateKey,
+			EnterpriseBaseUrl: auth.GithubApp.EnterpriseBaseUrl,
+		}
+	}
+	return credentials
+}
+
+func AskAboutGitContext(prompter Prompter, installOptions *install.CmdOptions, contexts *[]codefresh.ContextPayload) error {
 	if len(*contexts) < 1 {
 		return nil
 	}
+	if installOptions.Git.Integration == "" && installOptions.Git.Auth.GithubApp.AppID != 0 {
+		// github app is given with the local private key
+		return nil
+	}
 
-	var passwords = make(map[string]string)
-	var types = make(map[string]string)
+	var byName = make(map[string]codefresh.ContextPayload)
+	var byLabel = make(map[string]string)
 	var list []string
 	for _, v := range *contexts {
-		types[v.Metadata.Name] = v.Spec.Data.Auth.Type
-		passwords[v.Metadata.Name] = v.Spec.Data.Auth.Password
-		list = append(list, v.Metadata.Name)
+		label := fmt.Sprintf("%s (%s)", v.Metadata.Name, git.Provider(v))
+		byName[v.Metadata.Name] = v
+		byLabel[label] = v.Metadata.Name
+		list = append(list, label)
 	}
 
-	if len(list) == 1 {
-		installOptions.Git.Integration = list[0]
+	if installOptions.Git.Integration != "" {
+		if _, ok := byName[installOptions.Git.Integration]; !ok {
+			return errors.New(fmt.Sprintf("Git context \"%s\" is not available", installOptions.Git.Integration))
+		}
+	} else if len(list) == 1 {
+		installOptions.Git.Integration = byLabel[list[0]]
 	} else {
-		_, installOptions.Git.Integration = prompt.NewPrompt().Select(list, "Select Git context")
+		err, label := prompter.Select(list, "Select Git context")
+		if err != nil {
+			return err
+		}
+		installOptions.Git.Integration = byLabel[label]
 	}
 
-	logger.Info(fmt.Sprintf("Use \"%s\" git integration for integrate with manifest repo", installOptions.Git.Integration))
+	context := byName[installOptions.Git.Integration]
+	logger.Info(fmt.Sprintf("Use \"%s\" %s git integration for integrate with manifest repo", installOptions.Git.Integration, git.Provider(context)))
 
-	installOptions.Git.Auth.Type = types[installOptions.Git.Integration]
-	installOptions.Git.Auth.Pass = passwords[installOptions.Git.Integration]
+	credentials, err := git.Credentials(context)
+	if err != nil {
+		return err
+	}
+	installOptions.Git.Auth.Type = context.Spec.Data.Auth.Type
+	installOptions.Git.Auth.Username = credentials.Username
+	installOptions.Git.Auth.Pass = credentials.Password
+	if installOptions.Git.Auth.SshPrivateKey == "" {
+		installOptions.Git.Auth.SshPrivateKey = credentials.SshPrivateKey
+	}
+	if credentials.GithubApp != nil {
+		installOptions.Git.Auth.GithubApp = install.GithubAppOptions{
+			AppID:             credentials.GithubApp.AppID,
+			InstallationID:    credentials.GithubApp.InstallationID,
+			PrivateKey:        credentials.GithubApp.PrivateKey,
+			EnterpriseBaseUrl: credentials.GithubApp.EnterpriseBaseUrl,
+		}
+	}
 
 	return nil
 }
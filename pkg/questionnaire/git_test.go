@@ -0,0 +1,301 @@
+package questionnaire
+
+import (
+	"fmt"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
+	"github.com/codefresh-io/go-sdk/pkg/codefresh"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func gitContext(name string, contextType string, authType string, password string, sshKey string) codefresh.ContextPayload {
+	var context codefresh.ContextPayload
+	context.Metadata.Name = name
+	context.Spec.Type = contextType
+	context.Spec.Data.Auth.Type = authType
+	context.Spec.Data.Auth.Password = password
+	context.Spec.Data.Auth.SshPrivateKey = sshKey
+	return context
+}
+
+// newGitServer advertises main branch of /org/repo.git to user "git" with password "token"
+func newGitServer() *httptest.Server {
+	pktLine := func(line string) string {
+		return fmt.Sprintf("%04x%s", len(line)+4, line)
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/org/repo.git/info/refs" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if username, password, _ := r.BasicAuth(); username != "git" || password != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-git-upload-pack-advertisement")
+		_, _ = w.Write([]byte(pktLine("# service=git-upload-pack\n") + "0000" +
+			pktLine("95d09f2b10159347eece71399a7e2e907ea3df4f refs/heads/main\x00side-band-64k\n") + "0000"))
+	}))
+}
+
+func TestAskAboutManifestRepo(t *testing.T) {
+	tests := []struct {
+		name      string
+		prompter  prompterCase
+		answer    *bool
+		want      bool
+		wantAsked int
+	}{
+		{
+			name:     "declined in answers file is not asked",
+			prompter: scripted(),
+			answer:   boolAnswer(false),
+			want:     false,
+		},
+		{
+			name:      "confirmed answer is kept",
+			prompter:  scripted(true),
+			want:      true,
+			wantAsked: 1,
+		},
+		{
+			name:     "declined without explicit answer",
+			prompter: defaults,
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &install.CmdOptions{}
+			options.Git.AddRepo = tt.answer
+			prompter := tt.prompter()
+
+			got, err := AskAboutManifestRepo(prompter, options)
+			assertAsked(t, prompter, tt.wantAsked)
+			assertError(t, err, "")
+			if got != tt.want || *options.Git.AddRepo != tt.want {
+				t.Errorf("add repo = %v, answer %v, want %v", got, *options.Git.AddRepo, tt.want)
+			}
+		})
+	}
+}
+
+func TestAskAboutGitContext(t *testing.T) {
+	github := gitContext("github", "git.github", "basic", "ghp_token", "")
+	gitlab := gitContext("gitlab", "git.gitlab", "basic", "glpat_token", "")
+	gerrit := gitContext("gerrit", "git.gerrit", "ssh", "", "ssh key")
+	contexts := []codefresh.ContextPayload{github, gitlab, gerrit}
+
+	tests := []struct {
+		name         string
+		prompter     prompterCase
+		contexts     []codefresh.ContextPayload
+		integration  string
+		githubAppID  int64
+		want         string
+		wantUsername string
+		wantPassword string
+		wantSshKey   string
+		wantAsked    int
+		wantErr      string
+	}{
+		{
+			name:     "no git contexts",
+			prompter: scripted(),
+		},
+		{
+			name:        "local github app is not asked",
+			prompter:    scripted(),
+			contexts:    contexts,
+			githubAppID: 42,
+		},
+		{
+			name:         "integration of flags is not asked",
+			prompter:     scripted(),
+			contexts:     contexts,
+			integration:  "gitlab",
+			want:         "gitlab",
+			wantUsername: "oauth2",
+			wantPassword: "glpat_token",
+		},
+		{
+			name:        "unknown integration of flags",
+			prompter:    scripted(),
+			contexts:    contexts,
+			integration: "bitbucket",
+			wantErr:     "Git context \"bitbucket\" is not available",
+		},
+		{
+			name:         "single context is not asked",
+			prompter:     scripted(),
+			contexts:     []codefresh.ContextPayload{github},
+			want:         "github",
+			wantUsername: "git",
+			wantPassword: "ghp_token",
+		},
+		{
+			name:         "selected by provider label",
+			prompter:     scripted("gitlab (GitLab)"),
+			contexts:     contexts,
+			want:         "gitlab",
+			wantUsername: "oauth2",
+			wantPassword: "glpat_token",
+			wantAsked:    1,
+		},
+		{
+			name:       "ssh context provides private key only",
+			prompter:   scripted("gerrit (Gerrit)"),
+			contexts:   contexts,
+			want:       "gerrit",
+			wantSshKey: "ssh key",
+			wantAsked:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &install.CmdOptions{}
+			options.Git.Integration = tt.integration
+			options.Git.Auth.GithubApp.AppID = tt.githubAppID
+			prompter := tt.prompter()
+
+			err := AskAboutGitContext(prompter, options, &tt.contexts)
+			assertAsked(t, prompter, tt.wantAsked)
+			if assertError(t, err, tt.wantErr) {
+				return
+			}
+			auth := options.Git.Auth
+			if options.Git.Integration != tt.want || auth.Username != tt.wantUsername || auth.Pass != tt.wantPassword || auth.SshPrivateKey != tt.wantSshKey {
+				t.Errorf("integration = %q, username = %q, password = %q, ssh key = %q, want %q, %q, %q, %q",
+					options.Git.Integration, auth.Username, auth.Pass, auth.SshPrivateKey,
+					tt.want, tt.wantUsername, tt.wantPassword, tt.wantSshKey)
+			}
+		})
+	}
+}
+
+func TestAskAboutGitRepo(t *testing.T) {
+	server := newGitServer()
+	defer server.Close()
+	repo := server.URL + "/org/repo.git"
+	missingRepo := server.URL + "/org/missing.git"
+
+	tests := []struct {
+		name      string
+		prompter  prompterCase
+		password  string
+		sshKey    string
+		appID     int64
+		repoUrl   string
+		skipCheck bool
+		want      string
+		wantAsked int
+		wantErr   string
+	}{
+		{
+			name:     "no git credentials",
+			prompter: scripted(),
+		},
+		{
+			name:     "accessible repo of flags is not asked",
+			prompter: scripted(),
+			password: "token",
+			repoUrl:  repo,
+			want:     repo,
+		},
+		{
+			name:     "inaccessible repo of flags is not asked again",
+			prompter: scripted(repo),
+			password: "token",
+			repoUrl:  missingRepo,
+			wantErr:  "is not found",
+		},
+		{
+			name:     "inaccessible repo of flags is not replaced by default",
+			prompter: defaults,
+			password: "token",
+			repoUrl:  missingRepo,
+			wantErr:  "is not found",
+		},
+		{
+			name:     "wrong credentials",
+			prompter: scripted(),
+			password: "expired",
+			repoUrl:  repo,
+			wantErr:  "is denied",
+		},
+		{
+			name:      "inaccessible typed repo is asked again",
+			prompter:  scripted(missingRepo, "https://", repo),
+			password:  "token",
+			want:      repo,
+			wantAsked: 3,
+		},
+		{
+			name:      "no accessible repo after attempts",
+			prompter:  scripted(missingRepo, missingRepo, missingRepo, repo),
+			password:  "token",
+			wantAsked: 3,
+			wantErr:   "is not found",
+		},
+		{
+			name:      "example repo by default",
+			prompter:  scripted(""),
+			password:  "token",
+			skipCheck: true,
+			want:      defaultManifestRepo,
+			wantAsked: 1,
+		},
+		{
+			name:      "ssh example repo by default for ssh context",
+			prompter:  scripted(""),
+			sshKey:    "ssh key",
+			skipCheck: true,
+			want:      "git@github.com:argoproj/argocd-example-apps.git",
+			wantAsked: 1,
+		},
+		{
+			name:      "https example repo by default for github app",
+			prompter:  scripted(""),
+			appID:     42,
+			skipCheck: true,
+			want:      defaultManifestRepo,
+			wantAsked: 1,
+		},
+		{
+			name:      "syntax is checked without access check",
+			prompter:  scripted(),
+			password:  "token",
+			repoUrl:   "github.com/org/repo",
+			skipCheck: true,
+			wantErr:   "Invalid repo url",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &install.CmdOptions{}
+			options.Git.Auth.Username = "git"
+			options.Git.Auth.Pass = tt.password
+			options.Git.Auth.SshPrivateKey = tt.sshKey
+			options.Git.Auth.GithubApp.AppID = tt.appID
+			options.Git.RepoUrl = tt.repoUrl
+			options.Git.SkipRepoCheck = tt.skipCheck
+			prompter := tt.prompter()
+
+			err := AskAboutGitRepo(prompter, options, nil)
+			assertAsked(t, prompter, tt.wantAsked)
+			if assertError(t, err, tt.wantErr) {
+				if tt.repoUrl != "" && options.Git.RepoUrl != tt.repoUrl {
+					t.Errorf("repo url of flags is replaced by %q", options.Git.RepoUrl)
+				}
+				return
+			}
+			if options.Git.RepoUrl != tt.want {
+				t.Errorf("repo url = %q, want %q", options.Git.RepoUrl, tt.want)
+			}
+		})
+	}
+}
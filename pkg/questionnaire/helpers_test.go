@@ -0,0 +1,50 @@
+package questionnaire
+
+import (
+	"strings"
+	"testing"
+)
+
+// prompterCase builds a fresh prompter for every test case, scripted prompter keeps its asked questions
+type prompterCase func() Prompter
+
+func scripted(answers ...interface{}) prompterCase {
+	return func() Prompter {
+		return NewScriptedPrompter(answers...)
+	}
+}
+
+func defaults() Prompter {
+	return NewDefaultsPrompter()
+}
+
+func nonInteractive() Prompter {
+	return NewNonInteractivePrompter()
+}
+
+func boolAnswer(value bool) *bool {
+	return &value
+}
+
+// assertAsked checks number of questions asked by scripted prompter, other prompters don't record them
+func assertAsked(t *testing.T, prompter Prompter, want int) {
+	t.Helper()
+	if scriptedPrompter, ok := prompter.(*ScriptedPrompter); ok && len(scriptedPrompter.Asked) != want {
+		t.Errorf("asked %d questions %v, want %d", len(scriptedPrompter.Asked), scriptedPrompter.Asked, want)
+	}
+}
+
+// assertError checks the error against the expected substring and reports whether the case expected an error
+func assertError(t *testing.T, err error, wantErr string) bool {
+	t.Helper()
+	if wantErr == "" {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return false
+	}
+	if err == nil || !strings.Contains(err.Error(), wantErr) {
+		t.Fatalf("error = %v, want %q", err, wantErr)
+	}
+	return true
+}
@@ -2,15 +2,17 @@ package questionnaire
 
 import (
 	"github.com/codefresh-io/argocd-listener/installer/pkg/kube"
-	"github.com/codefresh-io/argocd-listener/installer/pkg/prompt"
 	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
 )
 
-func AskAboutKubeContext(installOptions *install.CmdOptions) error {
+// getAllContexts lists contexts of the kubeconfig, tests replace it to not depend on the local kubeconfig
+var getAllContexts = kube.GetAllContexts
+
+func AskAboutKubeContext(prompter Prompter, installOptions *install.CmdOptions) error {
 	kubeOptions := installOptions.Kube
 	kubeConfigPath := installOptions.Kube.ConfigPath
 	if kubeOptions.Context == "" {
-		contexts, err := kube.GetAllContexts(kubeConfigPath)
+		contexts, err := getAllContexts(kubeConfigPath)
 		if err != nil {
 			return err
 		}
@@ -18,7 +20,10 @@ func AskAboutKubeContext(installOptions *install.CmdOptions) error {
 		if len(contexts) == 1 {
 			kubeOptions.Context = contexts[0]
 		} else {
-			_, selectedContext := prompt.NewPrompt().Select(contexts, "Select Kubernetes context")
+			err, selectedContext := prompter.Select(contexts, "Select Kubernetes context")
+			if err != nil {
+				return err
+			}
 			kubeOptions.Context = selectedContext
 		}
 
@@ -29,6 +34,9 @@ func AskAboutKubeContext(installOptions *install.CmdOptions) error {
 
 func AskAboutManifest(installOptions *install.CmdOptions) error {
 	// dont need ask for now, customer can pass it use params
+	if installOptions.Kube.ManifestPath != "" {
+		return nil
+	}
 	installOptions.Kube.ManifestPath = "https://raw.githubusercontent.com/codefresh-io/argo-cd/v1.8.7/manifests/install.yaml"
 	return nil
 	//return prompt.InputWithDefault(&installOptions.Kube.ManifestPath, "Install manifest path/url", "https://raw.githubusercontent.com/argoproj/argo-cd/stable/manifests/install.yaml")
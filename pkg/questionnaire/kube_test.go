@@ -0,0 +1,75 @@
+package questionnaire
+
+import (
+	"errors"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
+	"testing"
+)
+
+func TestAskAboutKubeContext(t *testing.T) {
+	tests := []struct {
+		name        string
+		prompter    prompterCase
+		context     string
+		contexts    []string
+		contextsErr error
+		want        string
+		wantAsked   int
+		wantErr     string
+	}{
+		{
+			name:     "context of flags is not asked",
+			prompter: scripted(),
+			context:  "production",
+			contexts: []string{"staging", "production"},
+			want:     "production",
+		},
+		{
+			name:     "single context is not asked",
+			prompter: scripted(),
+			contexts: []string{"kind-kind"},
+			want:     "kind-kind",
+		},
+		{
+			name:      "selected among kubeconfig contexts",
+			prompter:  scripted("production"),
+			contexts:  []string{"staging", "production"},
+			want:      "production",
+			wantAsked: 1,
+		},
+		{
+			name:        "kubeconfig can't be read",
+			prompter:    scripted(),
+			contextsErr: errors.New("no kubeconfig"),
+			wantErr:     "no kubeconfig",
+		},
+	}
+
+	defer func(original func(string) ([]string, error)) {
+		getAllContexts = original
+	}(getAllContexts)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getAllContexts = func(kubeConfigPath string) ([]string, error) {
+				if kubeConfigPath != "/tmp/kubeconfig" {
+					t.Errorf("kubeconfig path = %q", kubeConfigPath)
+				}
+				return tt.contexts, tt.contextsErr
+			}
+			options := &install.CmdOptions{}
+			options.Kube.ConfigPath = "/tmp/kubeconfig"
+			options.Kube.Context = tt.context
+			prompter := tt.prompter()
+
+			err := AskAboutKubeContext(prompter, options)
+			assertAsked(t, prompter, tt.wantAsked)
+			if assertError(t, err, tt.wantErr) {
+				return
+			}
+			if options.Kube.Context != tt.want {
+				t.Errorf("context = %q, want %q", options.Kube.Context, tt.want)
+			}
+		})
+	}
+}
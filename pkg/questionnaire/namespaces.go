@@ -1,21 +1,25 @@
 package questionnaire
 
 import (
-	"github.com/codefresh-io/argocd-listener/installer/pkg/kube"
-	"github.com/codefresh-io/argocd-listener/installer/pkg/prompt"
 	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
 )
 
-func AskAboutNamespace(installOptions *install.CmdOptions, kubeClient kube.Kube) error {
+// NamespaceLister lists namespaces to select the installation namespace from,
+// it's the only part of the kube client the question needs
+type NamespaceLister interface {
+	GetNamespaces() ([]string, error)
+}
+
+func AskAboutNamespace(prompter Prompter, installOptions *install.CmdOptions, kubeClient NamespaceLister) error {
 	if installOptions.Kube.Namespace == "" {
 		namespaces, err := kubeClient.GetNamespaces()
 		if err != nil {
-			err = prompt.NewPrompt().InputWithDefault(&installOptions.Kube.Namespace, "Kubernetes namespace to install", "default")
+			err = prompter.InputWithDefault(&installOptions.Kube.Namespace, "Kubernetes namespace to install", "default")
 			if err != nil {
 				return err
 			}
 		} else {
-			err, selectedNamespace := prompt.NewPrompt().Select(namespaces, "Select the namespace")
+			err, selectedNamespace := prompter.Select(namespaces, "Select the namespace")
 			if err != nil {
 				return err
 			}
@@ -0,0 +1,74 @@
+package questionnaire
+
+import (
+	"errors"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
+	"testing"
+)
+
+type namespaceList struct {
+	namespaces []string
+	err        error
+}
+
+func (l namespaceList) GetNamespaces() ([]string, error) {
+	return l.namespaces, l.err
+}
+
+func TestAskAboutNamespace(t *testing.T) {
+	namespaces := namespaceList{namespaces: []string{"default", "argocd", "kube-system"}}
+	forbidden := namespaceList{err: errors.New("namespaces is forbidden")}
+
+	tests := []struct {
+		name      string
+		prompter  prompterCase
+		namespace string
+		lister    NamespaceLister
+		want      string
+		wantAsked int
+	}{
+		{
+			name:      "namespace of flags is not asked",
+			prompter:  scripted(),
+			namespace: "gitops",
+			lister:    namespaces,
+			want:      "gitops",
+		},
+		{
+			name:      "selected among cluster namespaces",
+			prompter:  scripted("argocd"),
+			lister:    namespaces,
+			want:      "argocd",
+			wantAsked: 1,
+		},
+		{
+			name:      "typed when namespaces can't be listed",
+			prompter:  scripted("gitops"),
+			lister:    forbidden,
+			want:      "gitops",
+			wantAsked: 1,
+		},
+		{
+			name:      "typed namespace defaults to default",
+			prompter:  scripted(""),
+			lister:    forbidden,
+			want:      "default",
+			wantAsked: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &install.CmdOptions{}
+			options.Kube.Namespace = tt.namespace
+			prompter := tt.prompter()
+
+			err := AskAboutNamespace(prompter, options, tt.lister)
+			assertAsked(t, prompter, tt.wantAsked)
+			assertError(t, err, "")
+			if options.Kube.Namespace != tt.want {
+				t.Errorf("namespace = %q, want %q", options.Kube.Namespace, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package questionnaire
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/install"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+const (
+	generatedPasswordLength = 24
+	passwordAlphabet        = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+// ValidatePassword checks the password against the policy
+func ValidatePassword(password string, policy install.PasswordPolicy) error {
+	if password == "" {
+		return errors.New("Password can't be empty")
+	}
+	if len(password) < policy.MinLength {
+		return errors.New(fmt.Sprintf("Password must be at least %d characters long", policy.MinLength))
+	}
+	if !policy.RequireComplexity {
+		return nil
+	}
+	var hasLower, hasUpper, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLower || !hasUpper || !hasDigit {
+		return errors.New("Password must contain lower and upper case letters and digits")
+	}
+	return nil
+}
+
+// GeneratePassword returns a random password which satisfies the policy
+func GeneratePassword(policy install.PasswordPolicy) (string, error) {
+	length := generatedPasswordLength
+	if policy.MinLength > length {
+		length = policy.MinLength
+	}
+	for {
+		var password strings.Builder
+		for i := 0; i < length; i++ {
+			index, err := rand.Int(rand.Reader, big.NewInt(int64(len(passwordAlphabet))))
+			if err != nil {
+				return "", err
+			}
+			password.WriteByte(passwordAlphabet[index.Int64()])
+		}
+		if ValidatePassword(password.String(), install.PasswordPolicy{MinLength: length, RequireComplexity: true}) == nil {
+			return password.String(), nil
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package questionnaire
+
+import (
+	"errors"
+	"fmt"
+	"github.com/codefresh-io/argocd-listener/installer/pkg/prompt"
+)
+
+type (
+	// Prompter asks the installation questions, the answers come from the terminal,
+	// a prepared script or the defaults of the questions
+	Prompter interface {
+		Confirm(message string) (error, bool)
+		Select(options []string, message string) (error, string)
+		Multiselect(options []string, message string) (error, []string)
+		InputWithDefault(target *string, message string, defaultValue string) error
+		InputPassword(target *string, message string) error
+	}
+
+	terminalPrompter struct{}
+
+	defaultsPrompter struct{}
+
+	nonInteractivePrompter struct{}
+
+	// ScriptedPrompter replays prepared answers in order and records the asked questions
+	ScriptedPrompter struct {
+		answers []interface{}
+		Asked   []string
+	}
+)
+
+func NewTerminalPrompter() Prompter {
+	return &terminalPrompter{}
+}
+
+// NewDefaultsPrompter returns prompter which accepts the default answer of every question,
+// confirmations are declined, so opting in requires an explicit answer
+func NewDefaultsPrompter() Prompter {
+	return &defaultsPrompter{}
+}
+
+// NewNonInteractivePrompter returns prompter which fails on every question
+func NewNonInteractivePrompter() Prompter {
+	return &nonInteractivePrompter{}
+}
+
+// NewScriptedPrompter returns prompter which answers with the given values,
+// bool for Confirm, string for Select and inputs, []string for Multiselect
+func NewScriptedPrompter(answers ...interface{}) *ScriptedPrompter {
+	return &ScriptedPrompter{answers: answers}
+}
+
+// isInteractivePrompter reports whether the prompter asks a person, so the wrong answer can be corrected
+// by asking again, the other prompters would repeat or fail
+func isInteractivePrompter(prompter Prompter) bool {
+	switch prompter.(type) {
+	case *defaultsPrompter, *nonInteractivePrompter:
+		return false
+	}
+	return true
+}
+
+func (p *terminalPrompter) Confirm(message string) (error, bool) {
+	return prompt.NewPrompt().Confirm(message)
+}
+
+func (p *terminalPrompter) Select(options []string, message string) (error, string) {
+	return prompt.NewPrompt().Select(options, message)
+}
+
+func (p *terminalPrompter) Multiselect(options []string, message string) (error, []string) {
+	return prompt.NewPrompt().Multiselect(options, message)
+}
+
+func (p *terminalPrompter) InputWithDefault(target *string, message string, defaultValue string) error {
+	return prompt.NewPrompt().InputWithDefault(target, message, defaultValue)
+}
+
+func (p *terminalPrompter) InputPassword(target *string, message string) error {
+	return prompt.NewPrompt().InputPassword(target, message)
+}
+
+func (p *defaultsPrompter) Confirm(message string) (error, bool) {
+	return nil, false
+}
+
+func (p *defaultsPrompter) Select(options []string, message string) (error, string) {
+	if len(options) == 0 {
+		return errors.New(fmt.Sprintf("No default answer for \"%s\"", message)), ""
+	}
+	return nil, options[0]
+}
+
+func (p *defaultsPrompter) Multiselect(options []string, message string) (error, []string) {
+	return nil, options
+}
+
+func (p *defaultsPrompter) InputWithDefault(target *string, message string, defaultValue string) error {
+	*target = defaultValue
+	return nil
+}
+
+func (p *defaultsPrompter) InputPassword(target *string, message string) error {
+	return errors.New(fmt.Sprintf("No default answer for \"%s\"", message))
+}
+
+func (p *nonInteractivePrompter) fail(message string) error {
+	return errors.New(fmt.Sprintf("Can't ask \"%s\", stdin is not a terminal", message))
+}
+
+func (p *nonInteractivePrompter) Confirm(message string) (error, bool) {
+	return p.fail(message), false
+}
+
+func (p *nonInteractivePrompter) Select(options []string, message string) (error, string) {
+	return p.fail(message), ""
+}
+
+func (p *nonInteractivePrompter) Multiselect(options []string, message string) (error, []string) {
+	return p.fail(message), nil
+}
+
+func (p *nonInteractivePrompter) InputWithDefault(target *string, message string, defaultValue string) error {
+	return p.fail(message)
+}
+
+func (p *nonInteractivePrompter) InputPassword(target *string, message string) error {
+	return p.fail(message)
+}
+
+func (p *ScriptedPrompter) next(message string) (interface{}, error) {
+	p.Asked = append(p.Asked, message)
+	if len(p.answers) == 0 {
+		return nil, errors.New(fmt.Sprintf("No scripted answer for \"%s\"", message))
+	}
+	answer := p.answers[0]
+	p.answers = p.answers[1:]
+	return answer, nil
+}
+
+func (p *ScriptedPrompter) nextString(message string) (string, error) {
+	answer, err := p.next(message)
+	if err != nil {
+		return "", err
+	}
+	value, ok := answer.(string)
+	if !ok {
+		return "", errors.New(fmt.Sprintf("Scripted answer for \"%s\" should be string, got %T", message, answer))
+	}
+	return value, nil
+}
+
+func (p *ScriptedPrompter) Confirm(message string) (error, bool) {
+	answer, err := p.next(message)
+	if err != nil {
+		return err, false
+	}
+	value, ok := answer.(bool)
+	if !ok {
+		return errors.New(fmt.Sprintf("Scripted answer for \"%s\" should be bool, got %T", message, answer)), false
+	}
+	return nil, value
+}
+
+func (p *ScriptedPrompter) Select(options []string, message string) (error, string) {
+	value, err := p.nextString(message)
+	if err != nil {
+		return err, ""
+	}
+	for _, option := range options {
+		if option == value {
+			return nil, value
+		}
+	}
+	return errors.New(fmt.Sprintf("Scripted answer \"%s\" is not an option of \"%s\"", value, message)), ""
+}
+
+func (p *ScriptedPrompter) Multiselect(options []string, message string) (error, []string) {
+	answer, err := p.next(message)
+	if err != nil {
+		return err, nil
+	}
+	values, ok := answer.([]string)
+	if !ok {
+		return errors.New(fmt.Sprintf("Scripted answer for \"%s\" should be []string, got %T", message, answer)), nil
+	}
+	return nil, values
+}
+
+func (p *ScriptedPrompter) InputWithDefault(target *string, message string, defaultValue string) error {
+	value, err := p.nextString(message)
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		value = defaultValue
+	}
+	*target = value
+	return nil
+}
+
+func (p *ScriptedPrompter) InputPassword(target *string, message string) error {
+	value, err := p.nextString(message)
+	if err != nil {
+		return err
+	}
+	*target = value
+	return nil
+}
@@ -0,0 +1,110 @@
+package questionnaire
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScriptedPrompter(t *testing.T) {
+	prompter := NewScriptedPrompter(true, "b", []string{"x", "y"}, "", "secret", "c")
+
+	err, confirmed := prompter.Confirm("confirm?")
+	if err != nil || !confirmed {
+		t.Fatalf("Confirm = %v, %v", err, confirmed)
+	}
+	err, selected := prompter.Select([]string{"a", "b"}, "select")
+	if err != nil || selected != "b" {
+		t.Fatalf("Select = %v, %v", err, selected)
+	}
+	err, multiselected := prompter.Multiselect([]string{"x", "y", "z"}, "multiselect")
+	if err != nil || !reflect.DeepEqual(multiselected, []string{"x", "y"}) {
+		t.Fatalf("Multiselect = %v, %v", err, multiselected)
+	}
+	var input, password string
+	err = prompter.InputWithDefault(&input, "input", "default")
+	if err != nil || input != "default" {
+		t.Fatalf("InputWithDefault = %v, %v", err, input)
+	}
+	err = prompter.InputPassword(&password, "password")
+	if err != nil || password != "secret" {
+		t.Fatalf("InputPassword = %v, %v", err, password)
+	}
+	err, _ = prompter.Select([]string{"a", "b"}, "not an option")
+	assertError(t, err, "is not an option")
+	err, _ = prompter.Confirm("no answers left")
+	assertError(t, err, "No scripted answer")
+
+	want := []string{"confirm?", "select", "multiselect", "input", "password", "not an option", "no answers left"}
+	if !reflect.DeepEqual(prompter.Asked, want) {
+		t.Errorf("Asked = %v, want %v", prompter.Asked, want)
+	}
+}
+
+func TestScriptedPrompterAnswerType(t *testing.T) {
+	err, _ := NewScriptedPrompter("yes").Confirm("confirm?")
+	assertError(t, err, "should be bool")
+	err, _ = NewScriptedPrompter(true).Select([]string{"a"}, "select")
+	assertError(t, err, "should be string")
+	err, _ = NewScriptedPrompter("x").Multiselect([]string{"x"}, "multiselect")
+	assertError(t, err, "should be []string")
+}
+
+func TestDefaultsPrompter(t *testing.T) {
+	prompter := NewDefaultsPrompter()
+
+	err, confirmed := prompter.Confirm("confirm?")
+	if err != nil || confirmed {
+		t.Errorf("Confirm = %v, %v", err, confirmed)
+	}
+	err, selected := prompter.Select([]string{"a", "b"}, "select")
+	if err != nil || selected != "a" {
+		t.Errorf("Select = %v, %v", err, selected)
+	}
+	err, _ = prompter.Select(nil, "select from nothing")
+	assertError(t, err, "No default answer for \"select from nothing\"")
+	err, multiselected := prompter.Multiselect([]string{"x", "y"}, "multiselect")
+	if err != nil || !reflect.DeepEqual(multiselected, []string{"x", "y"}) {
+		t.Errorf("Multiselect = %v, %v", err, multiselected)
+	}
+	var input, password string
+	err = prompter.InputWithDefault(&input, "input", "default")
+	if err != nil || input != "default" {
+		t.Errorf("InputWithDefault = %v, %v", err, input)
+	}
+	err = prompter.InputPassword(&password, "password")
+	assertError(t, err, "No default answer for \"password\"")
+}
+
+func TestNonInteractivePrompter(t *testing.T) {
+	prompter := NewNonInteractivePrompter()
+	var input string
+
+	err, _ := prompter.Confirm("confirm?")
+	assertError(t, err, "Can't ask \"confirm?\", stdin is not a terminal")
+	err, _ = prompter.Select([]string{"a"}, "select")
+	assertError(t, err, "stdin is not a terminal")
+	err, _ = prompter.Multiselect([]string{"x"}, "multiselect")
+	assertError(t, err, "stdin is not a terminal")
+	err = prompter.InputWithDefault(&input, "input", "default")
+	assertError(t, err, "stdin is not a terminal")
+	err = prompter.InputPassword(&input, "password")
+	assertError(t, err, "stdin is not a terminal")
+}
+
+func TestIsInteractivePrompter(t *testing.T) {
+	tests := []struct {
+		prompter Prompter
+		want     bool
+	}{
+		{NewTerminalPrompter(), true},
+		{NewScriptedPrompter(), true},
+		{NewDefaultsPrompter(), false},
+		{NewNonInteractivePrompter(), false},
+	}
+
+	for _, tt := range tests {
+		if got := isInteractivePrompter(tt.prompter); got != tt.want {
+			t.Errorf("isInteractivePrompter(%T) = %v, want %v", tt.prompter, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package rollback
+
+import (
+	"errors"
+	"fmt"
+	"github.com/codefresh-io/cf-gitops-controller/pkg/logger"
+	"strings"
+)
+
+type (
+	// Journal keeps the actions which reverse the mutating steps of the installation
+	Journal struct {
+		entries []entry
+	}
+
+	entry struct {
+		description string
+		undo        func() error
+	}
+)
+
+func New() *Journal {
+	return &Journal{}
+}
+
+// Record adds the action which reverses a mutating step, records of nil journal are ignored
+func (j *Journal) Record(description string, undo func() error) {
+	if j == nil {
+		return
+	}
+	j.entries = append(j.entries, entry{description: description, undo: undo})
+}
+
+// Rollback runs the recorded actions in reverse order, failed actions are reported and skipped
+func (j *Journal) Rollback() error {
+	if j == nil {
+		return nil
+	}
+	var failed []string
+	for i := len(j.entries) - 1; i >= 0; i-- {
+		entry := j.entries[i]
+		logger.Info(fmt.Sprintf("Rollback: %s", entry.description))
+		err := entry.undo()
+		if err != nil {
+			logger.Warning(fmt.Sprintf("Rollback \"%s\" failed: %v", entry.description, err))
+			failed = append(failed, entry.description)
+		}
+	}
+	j.entries = nil
+	if len(failed) > 0 {
+		return errors.New(fmt.Sprintf("Rollback failed for: %s", strings.Join(failed, ", ")))
+	}
+	return nil
+}